@@ -0,0 +1,29 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/filecoin-project/go-jsonrpc"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/spark8899/lotus_exporter/lotusinfo"
+)
+
+// newFullNodeClient connects to the lotus daemon described by apiInfo (TOKEN:MULTIADDR).
+func newFullNodeClient(ctx context.Context, apiInfo string) (lotusapi.FullNodeStruct, jsonrpc.ClientCloser, error) {
+	apiInfoS := lotusinfo.ParseApiInfo(strings.TrimSpace(apiInfo))
+	headers := http.Header{"Authorization": []string{"Bearer " + string(apiInfoS.Token)}}
+	var fuApi lotusapi.FullNodeStruct
+	closer, err := jsonrpc.NewMergeClient(ctx, apiInfoS.Addr, "Filecoin", []interface{}{&fuApi.Internal, &fuApi.CommonStruct.Internal, &fuApi.NetStruct.Internal}, headers)
+	return fuApi, closer, err
+}
+
+// newMinerClient connects to the lotus-miner described by apiInfo (TOKEN:MULTIADDR).
+func newMinerClient(ctx context.Context, apiInfo string) (lotusapi.StorageMinerStruct, jsonrpc.ClientCloser, error) {
+	apiInfoS := lotusinfo.ParseApiInfo(strings.TrimSpace(apiInfo))
+	headers := http.Header{"Authorization": []string{"Bearer " + string(apiInfoS.Token)}}
+	var miApi lotusapi.StorageMinerStruct
+	closer, err := jsonrpc.NewMergeClient(ctx, apiInfoS.Addr, "Filecoin", []interface{}{&miApi.Internal, &miApi.CommonStruct.Internal, &miApi.NetStruct.Internal}, headers)
+	return miApi, closer, err
+}
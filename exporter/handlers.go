@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/spark8899/lotus_exporter/lotusinfo"
+)
+
+// mpoolLocalHandler serves the local mpool messages seen by the last scrape.
+func (collector *lotusCollector) mpoolLocalHandler(w http.ResponseWriter, r *http.Request) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	msgs := collector.mpoolMsgs
+	if msgs == nil {
+		msgs = []lotusinfo.MpoolMsg{}
+	}
+	writeJSON(w, msgs)
+}
+
+// overdueJobsHandler serves the running jobs that exceeded their maximum duration at the last scrape.
+func (collector *lotusCollector) overdueJobsHandler(w http.ResponseWriter, r *http.Request) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	jobs := collector.overdueJobs
+	if jobs == nil {
+		jobs = []lotusinfo.JobInfoStruct{}
+	}
+	writeJSON(w, jobs)
+}
+
+// schedDiagHandler serves the raw sealing scheduler diagnostics of the miner.
+func (collector *lotusCollector) schedDiagHandler(w http.ResponseWriter, r *http.Request) {
+	miApi, closer, err := newMinerClient(r.Context(), collector.ltOptions.MinerApiInfo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer closer()
+
+	schedDiag, err := miApi.SealingSchedDiag(r.Context(), false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, schedDiag)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write json response: %s", err)
+	}
+}
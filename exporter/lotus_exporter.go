@@ -2,14 +2,16 @@ package exporter
 
 import (
 	"context"
-	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
 	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/spark8899/lotus_exporter/lotusinfo"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/version"
@@ -21,8 +23,13 @@ const (
 
 // LotusOpt is for option
 type LotusOpt struct {
-	FullNodeApiInfo string
-	MinerApiInfo    string
+	FullNodeApiInfo  string
+	MinerApiInfo     string
+	MpoolStuckEpochs int64
+	BasefeeWindows   []int64
+	JobMaxDurations  map[string]time.Duration
+	JobDetails       bool
+	GasLimits        map[string]int64
 }
 
 // setting collector
@@ -31,12 +38,56 @@ type lotusCollector struct {
 	lotusLocalTime           *prometheus.Desc
 	lotusChainBasefee        *prometheus.Desc
 	lotusChainHeight         *prometheus.Desc
+	lotusChainBasefeeWindow  *prometheus.Desc
+	lotusChainBasefeeNext    *prometheus.Desc
+	lotusChainHeadAge        *prometheus.Desc
+	lotusChainExpectedHeight *prometheus.Desc
+	lotusChainHeightDrift    *prometheus.Desc
+	lotusChainHeadChanges    *prometheus.Desc
+	lotusChainNullRounds     *prometheus.Desc
+	lotusChainBlocks         *prometheus.Desc
+	lotusChainTipsetBlocks   *prometheus.Desc
+	lotusChainArrivalDelay   *prometheus.Desc
+	lotusChainReorgs         *prometheus.Desc
+	lotusChainReorgDepth     *prometheus.Desc
+	lotusChainReorgOwnBlocks *prometheus.Desc
+	lotusChainMessages       *prometheus.Desc
+	lotusChainMessageGasUsed *prometheus.Desc
+	lotusChainTipsetGasUsed  *prometheus.Desc
+	lotusChainTipsetGasLimit *prometheus.Desc
 	lotusChainSyncDiff       *prometheus.Desc
 	lotusChainSyncStatus     *prometheus.Desc
+	lotusChainSyncStage      *prometheus.Desc
+	lotusChainSyncHeight     *prometheus.Desc
+	lotusChainSyncStart      *prometheus.Desc
+	lotusChainSyncDuration   *prometheus.Desc
+	lotusChainSyncErrors     *prometheus.Desc
+	lotusChainSyncErrorMsgs  *prometheus.Desc
 	lotusMpoolTotal          *prometheus.Desc
 	lotusMpoolLocalTotal     *prometheus.Desc
-	lotusMpoolLocalMessage   *prometheus.Desc
+	lotusMpoolLocalPending   *prometheus.Desc
+	lotusMpoolLocalFeeCap    *prometheus.Desc
+	lotusMpoolLocalNonce     *prometheus.Desc
+	lotusMpoolLocalMaxFeeCap *prometheus.Desc
+	lotusMpoolLocalFeeRatio  *prometheus.Desc
+	lotusMpoolLocalOldestAge *prometheus.Desc
+	lotusMpoolLocalStuck     *prometheus.Desc
+	lotusMpoolLocalStuckFee  *prometheus.Desc
+	lotusMpoolNonceGaps      *prometheus.Desc
+	lotusWalletNonce         *prometheus.Desc
+	lotusWalletGasSpent      *prometheus.Desc
+	lotusWalletMessages      *prometheus.Desc
+	lotusGasEstimateFee      *prometheus.Desc
+	lotusGasEstimatePremium  *prometheus.Desc
+	lotusGasEstimateLimit    *prometheus.Desc
 	lotusPower               *prometheus.Desc
+	lotusNetworkSupply       *prometheus.Desc
+	lotusNetworkPledge       *prometheus.Desc
+	lotusNetworkEpochReward  *prometheus.Desc
+	lotusNetworkBaseline     *prometheus.Desc
+	lotusSectorPledge        *prometheus.Desc
+	lotusSectorDailyReward   *prometheus.Desc
+	lotusSectorBreakEven     *prometheus.Desc
 	lotusPowerEligibility    *prometheus.Desc
 	lotusWalletBalance       *prometheus.Desc
 	lotusWalletLockedBalance *prometheus.Desc
@@ -52,15 +103,48 @@ type lotusCollector struct {
 	minerWorkerVmemTasks     *prometheus.Desc
 	minerWorkerCpuUsed       *prometheus.Desc
 	minerWorkerGpuUsed       *prometheus.Desc
-	minerWorkerJob           *prometheus.Desc
+	minerWorkerGpuInfo       *prometheus.Desc
+	minerWorkerEnabled       *prometheus.Desc
+	minerWorkerTaskResources *prometheus.Desc
+	minerWorkerJobs          *prometheus.Desc
+	minerJobDuration         *prometheus.Desc
+	minerJobOldest           *prometheus.Desc
+	minerJobsOverdue         *prometheus.Desc
+	minerSchedRequests       *prometheus.Desc
+	minerSchedOpenWindows    *prometheus.Desc
+	minerSchedWork           *prometheus.Desc
+	minerSectorStateEntered  *prometheus.Desc
+	minerSectorStateDuration *prometheus.Desc
+	minerSectorStageDuration *prometheus.Desc
+	minerSealDelay           *prometheus.Desc
+	minerExpectedSealTime    *prometheus.Desc
+	minerControlAddress      *prometheus.Desc
+	minerFallbackDisabled    *prometheus.Desc
+	minerBatchQueueSectors   *prometheus.Desc
+	minerBatchQueueOldest    *prometheus.Desc
+	minerBatchQueueDeadline  *prometheus.Desc
 
 	ltOptions LotusOpt
+
+	// last local mpool messages, served as JSON on /mpool/local
+	mutex     sync.Mutex
+	mpoolMsgs []lotusinfo.MpoolMsg
+	// last overdue jobs, served as JSON on /jobs/overdue when JobDetails is set
+	overdueJobs []lotusinfo.JobInfoStruct
+
+	mpoolTracker  *lotusinfo.MpoolTracker
+	headWatcher   *lotusinfo.HeadWatcher
+	basefeeHist   *lotusinfo.BasefeeHistory
+	msgTracker    *lotusinfo.MessageTracker
+	jobTracker    *lotusinfo.JobTracker
+	sectorTracker *lotusinfo.SectorTracker
+	batchQueues   *lotusinfo.BatchQueueTracker
 }
 
 //You must create a constructor for your collector that
 //initializes every descriptor and returns a pointer to the collector
 func newLotusCollector(opts *LotusOpt) *lotusCollector {
-	return &lotusCollector{
+	collector := &lotusCollector{
 		lotusLocalTime: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "local_time"),
 			"lotus_local_time time on the node machine when last execution start in epoch",
 			nil, nil,
@@ -74,7 +158,75 @@ func newLotusCollector(opts *LotusOpt) *lotusCollector {
 			[]string{"miner_id"}, nil,
 		),
 		lotusChainBasefee: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_basefee"),
-			"return current basefee",
+			"return current basefee in attoFIL",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainBasefeeWindow: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_basefee_window"),
+			"return min, avg and max basefee in attoFIL over the last window epochs",
+			[]string{"miner_id", "window", "stat"}, nil,
+		),
+		lotusChainBasefeeNext: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_basefee_next"),
+			"return predicted basefee of the next tipset in attoFIL",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainHeadAge: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_head_age_seconds"),
+			"return seconds since the head tipset timestamp",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainExpectedHeight: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_expected_height"),
+			"return current epoch expected from the genesis time and block delay",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainHeightDrift: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_height_drift"),
+			"return expected height minus current height",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainHeadChanges: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_head_changes_total"),
+			"return number of head changes received from ChainNotify by type (apply, revert), a tipset widening with new blocks is not an apply",
+			[]string{"miner_id", "type"}, nil,
+		),
+		lotusChainNullRounds: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_null_rounds_total"),
+			"return number of null rounds between applied tipsets",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainBlocks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_blocks_total"),
+			"return number of new blocks in applied tipsets, a block is not counted again when its tipset widens",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainTipsetBlocks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_tipset_blocks"),
+			"return number of blocks in the last applied tipset",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainArrivalDelay: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_tipset_arrival_delay_seconds"),
+			"return delay between the scheduled epoch start and the tipset arrival",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainMessages: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_messages_total"),
+			"return number of messages executed in applied tipsets by actor type and method",
+			[]string{"miner_id", "actor_type", "method"}, nil,
+		),
+		lotusChainMessageGasUsed: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_message_gas_used_total"),
+			"return gas used by messages executed in applied tipsets by actor type and method",
+			[]string{"miner_id", "actor_type", "method"}, nil,
+		),
+		lotusChainTipsetGasUsed: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_tipset_gas_used"),
+			"return gas used by the messages of the last executed tipset",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainTipsetGasLimit: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_tipset_gas_limit"),
+			"return block gas limit times the number of blocks of the last executed tipset",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainReorgs: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_reorgs_total"),
+			"return number of head changes that reverted blocks not applied again",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainReorgDepth: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_reorg_depth"),
+			"return number of tipsets that lost blocks per reorg",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainReorgOwnBlocks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_reorg_own_blocks_total"),
+			"return number of blocks mined by this miner that were reverted and not applied again",
 			[]string{"miner_id"}, nil,
 		),
 		lotusChainSyncDiff: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_diff"),
@@ -85,6 +237,30 @@ func newLotusCollector(opts *LotusOpt) *lotusCollector {
 			"return daemon sync status with chainhead for each daemon worker",
 			[]string{"miner_id", "worker_id"}, nil,
 		),
+		lotusChainSyncStage: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_stage"),
+			"return 1 for the current sync stage of each daemon worker and 0 for the others",
+			[]string{"miner_id", "worker_id", "stage"}, nil,
+		),
+		lotusChainSyncHeight: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_height"),
+			"return base, target and current height of each daemon sync worker",
+			[]string{"miner_id", "worker_id", "height_type"}, nil,
+		),
+		lotusChainSyncStart: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_start_time"),
+			"return start time of the sync of each daemon worker in epoch",
+			[]string{"miner_id", "worker_id"}, nil,
+		),
+		lotusChainSyncDuration: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_duration_seconds"),
+			"return duration of the sync of each daemon worker, up to now if still running",
+			[]string{"miner_id", "worker_id"}, nil,
+		),
+		lotusChainSyncErrors: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_errors"),
+			"return number of daemon sync workers in the error stage",
+			[]string{"miner_id"}, nil,
+		),
+		lotusChainSyncErrorMsgs: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "chain_sync_error_messages"),
+			"return number of distinct error messages of the daemon sync workers in the error stage",
+			[]string{"miner_id"}, nil,
+		),
 		lotusMpoolTotal: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_total"),
 			"return number of message pending in mpool",
 			[]string{"miner_id"}, nil,
@@ -93,25 +269,108 @@ func newLotusCollector(opts *LotusOpt) *lotusCollector {
 			"return number of messages pending in local mpool",
 			[]string{"miner_id"}, nil,
 		),
-		lotusMpoolLocalMessage: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_message"),
-			"local message details",
-			[]string{"miner_id", "msg_from", "msg_to", "msg_nonce", "msg_value", "msg_gaslimit", "msg_gasfeecap", "msg_gaspremium",
-				"msg_method", "msg_method_type", "msg_to_actor_type"}, nil,
+		lotusMpoolLocalPending: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_pending"),
+			"return number of pending local messages per sender and method type",
+			[]string{"miner_id", "msg_from", "msg_method_type"}, nil,
+		),
+		lotusMpoolLocalFeeCap: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_gas_fee_cap_total"),
+			"return sum of GasFeeCap * GasLimit of pending local messages per sender and method type in FIL",
+			[]string{"miner_id", "msg_from", "msg_method_type"}, nil,
+		),
+		lotusMpoolLocalNonce: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_lowest_nonce"),
+			"return lowest nonce of pending local messages per sender",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolLocalMaxFeeCap: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_max_gas_fee_cap"),
+			"return highest GasFeeCap of pending local messages per sender in attoFIL",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolLocalFeeRatio: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_max_gas_fee_cap_basefee_ratio"),
+			"return highest GasFeeCap of pending local messages per sender divided by current basefee",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolLocalOldestAge: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_oldest_age_seconds"),
+			"return age of the oldest pending local message per sender since first seen by the exporter",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolLocalStuck: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_stuck"),
+			"return number of local messages pending longer than MPOOL_STUCK_EPOCHS per sender",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolLocalStuckFee: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_local_stuck_below_basefee"),
+			"return number of stuck local messages whose GasFeeCap is below current basefee per sender",
+			[]string{"miner_id", "msg_from"}, nil,
+		),
+		lotusMpoolNonceGaps: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "mpool_nonce_gaps"),
+			"return number of missing nonces between the on-chain nonce and the highest pending nonce",
+			[]string{"miner_id", "address"}, nil,
+		),
+		lotusWalletGasSpent: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wallet_gas_spent_total"),
+			"return FIL spent on gas by landed messages of owner, worker and control addresses per method, role is owner, worker or controlN, fee_type is burn, tip or penalty",
+			[]string{"miner_id", "address", "role", "method", "fee_type"}, nil,
+		),
+		lotusWalletMessages: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wallet_messages_landed_total"),
+			"return number of landed messages of owner, worker and control addresses per method, role is owner, worker or controlN",
+			[]string{"miner_id", "address", "role", "method"}, nil,
+		),
+		lotusWalletNonce: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wallet_nonce"),
+			"return on-chain actor nonce (source=chain) and next mpool nonce (source=mpool)",
+			[]string{"miner_id", "address", "source"}, nil,
+		),
+		lotusGasEstimateFee: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "gas_estimate_fee"),
+			"return predicted fee of the miner message in FIL, expected is GasLimit*(basefee+premium), max is GasLimit*GasFeeCap",
+			[]string{"miner_id", "method", "bound"}, nil,
+		),
+		lotusGasEstimatePremium: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "gas_estimate_premium"),
+			"return estimated GasPremium of the miner message in attoFIL",
+			[]string{"miner_id", "method"}, nil,
+		),
+		lotusGasEstimateLimit: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "gas_estimate_limit"),
+			"return gas limit the miner message fee is estimated with, source=landed is the average of recently landed messages, static the configured GAS_LIMITS",
+			[]string{"miner_id", "method", "source"}, nil,
 		),
 		lotusPower: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "power"),
-			"return miner power",
+			"return miner power in bytes",
 			[]string{"miner_id", "scope", "power_type"}, nil,
 		),
+		lotusNetworkSupply: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "network_supply"),
+			"return circulating supply components in FIL (vested, mined, burnt, locked, circulating, reserve_disbursed)",
+			[]string{"miner_id", "supply_type"}, nil,
+		),
+		lotusNetworkPledge: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "network_pledge_total"),
+			"return total network pledge collateral in FIL",
+			[]string{"miner_id"}, nil,
+		),
+		lotusNetworkEpochReward: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "network_epoch_reward"),
+			"return reward actor ThisEpochReward in FIL",
+			[]string{"miner_id"}, nil,
+		),
+		lotusNetworkBaseline: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "network_baseline_power"),
+			"return reward actor ThisEpochBaselinePower in bytes",
+			[]string{"miner_id"}, nil,
+		),
+		lotusSectorPledge: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "sector_pledge"),
+			"return initial pledge required for a new sector in FIL",
+			[]string{"miner_id", "sector_size", "deal_type"}, nil,
+		),
+		lotusSectorDailyReward: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "sector_daily_reward"),
+			"return expected daily block reward of a new sector in FIL",
+			[]string{"miner_id", "sector_size", "deal_type"}, nil,
+		),
+		lotusSectorBreakEven: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "sector_break_even_days"),
+			"return days of expected reward needed to pay the PreCommitSector and ProveCommitSector gas of a new sector",
+			[]string{"miner_id", "sector_size", "deal_type"}, nil,
+		),
 		lotusPowerEligibility: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "power_mining_eligibility"),
 			"return miner mining eligibility",
 			[]string{"miner_id"}, nil,
 		),
 		lotusWalletBalance: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wallet_balance"),
-			"return wallet balance",
+			"return wallet balance in FIL",
 			[]string{"miner_id", "address", "name"}, nil,
 		),
 		lotusWalletLockedBalance: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wallet_locked_balance"),
-			"return miner wallet locked funds",
+			"return miner wallet locked funds in FIL",
 			[]string{"miner_id", "address", "locked_type"}, nil,
 		),
 		minerInfo: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_info"),
@@ -124,51 +383,139 @@ func newLotusCollector(opts *LotusOpt) *lotusCollector {
 		),
 		minerWorkerCpu: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_cpu"),
 			"number of CPU used by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerGpu: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_gpu"),
 			"is the GPU used by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerRamTotal: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_ram_total"),
 			"worker server RAM",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerRamReserved: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_ram_reserved"),
 			"worker memory reserved by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerRamTasks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_ram_tasks"),
 			"worker minimal memory used",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerVmemTotal: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_vmem_total"),
 			"server Physical RAM + Swap",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerVmemReserved: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_vmem_reserved"),
 			"worker VMEM used by on-going tasks",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerVmemTasks: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_vmem_tasks"),
 			"worker VMEM reserved by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerCpuUsed: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_cpu_used"),
 			"number of CPU used by lotused by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
 		),
 		minerWorkerGpuUsed: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_gpu_used"),
-			"is the GPU used by lotus",
-			[]string{"miner_id", "worker_host"}, nil,
+			"number of GPUs used by lotus tasks, fractional when tasks share a GPU",
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
+		),
+		minerWorkerGpuInfo: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_gpu_info"),
+			"GPUs of the worker, gpu is the index and name the model reported by the worker",
+			[]string{"miner_id", "worker_id", "worker_host", "gpu", "name"}, nil,
+		),
+		minerWorkerEnabled: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_enabled"),
+			"is the worker enabled for scheduling",
+			[]string{"miner_id", "worker_id", "worker_host"}, nil,
+		),
+		minerWorkerTaskResources: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_task_resources"),
+			"worker resource limits per task type for the miner sector size (min_memory, max_memory, base_min_memory in bytes, gpu_utilization, max_parallelism, max_parallelism_gpu)",
+			[]string{"miner_id", "worker_id", "worker_host", "task", "resource"}, nil,
+		),
+		minerWorkerJobs: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_jobs"),
+			"number of jobs on each worker per task type and state (running, prepared, assigned, ret_wait, returned, ret_done)",
+			[]string{"miner_id", "worker_id", "worker_host", "task", "state"}, nil,
+		),
+		minerJobDuration: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_job_duration_seconds"),
+			"duration of completed running jobs per task type",
+			[]string{"miner_id", "task"}, nil,
+		),
+		minerJobOldest: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_job_oldest_running_seconds"),
+			"age of the oldest running job per task type",
+			[]string{"miner_id", "task"}, nil,
 		),
-		minerWorkerJob: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_job"),
-			"status of each individual job running on the workers. Value is the duration",
-			[]string{"miner_id", "job_id", "worker_host", "task", "sector_id", "job_start_time", "run_wait"}, nil,
+		minerJobsOverdue: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_worker_jobs_overdue"),
+			"number of running jobs exceeding the maximum duration of their task type per worker",
+			[]string{"miner_id", "worker_id", "worker_host", "task"}, nil,
+		),
+		minerSchedRequests: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sched_requests"),
+			"number of requests waiting in the sealing scheduler per task type and priority",
+			[]string{"miner_id", "task", "priority"}, nil,
+		),
+		minerSchedOpenWindows: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sched_open_windows"),
+			"number of open scheduling windows per worker",
+			[]string{"miner_id", "worker_id"}, nil,
+		),
+		minerSchedWork: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sched_work"),
+			"number of tracked work items in the sealing manager by state (returned, waiting, early_returned, call_to_work)",
+			[]string{"miner_id", "state"}, nil,
+		),
+		minerSectorStateEntered: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sector_state_entered_total"),
+			"number of sectors entering each sealing state since the exporter started, from their event log",
+			[]string{"miner_id", "state"}, nil,
+		),
+		minerSectorStateDuration: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sector_state_duration_seconds"),
+			"time spent by sectors in each sealing state they left since the exporter started",
+			[]string{"miner_id", "state"}, nil,
+		),
+		minerSectorStageDuration: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sector_stage_duration_seconds"),
+			"duration of each sealing stage of the sectors that reached Proving since the exporter started, from the sector event log",
+			[]string{"miner_id", "stage"}, nil,
+		),
+		minerSealDelay: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sealing_seal_delay_seconds"),
+			"return time a new sector waits for deals before sealing starts",
+			[]string{"miner_id"}, nil,
+		),
+		minerExpectedSealTime: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_sealing_expected_seal_duration_seconds"),
+			"return expected time for a sector to seal",
+			[]string{"miner_id"}, nil,
+		),
+		minerControlAddress: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_control_address_info"),
+			"return 1 for each control address configured per use (precommit, commit, terminate, deal_publish)",
+			[]string{"miner_id", "use", "address"}, nil,
+		),
+		minerFallbackDisabled: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_control_fallback_disabled"),
+			"return 1 if falling back to the owner or worker address for messages is disabled",
+			[]string{"miner_id", "role"}, nil,
+		),
+		minerBatchQueueSectors: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_batch_queue_sectors"),
+			"return number of sectors waiting in the precommit or commit batch queue",
+			[]string{"miner_id", "queue"}, nil,
+		),
+		minerBatchQueueOldest: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_batch_queue_oldest_seconds"),
+			"return age of the oldest sector waiting in the precommit or commit batch queue",
+			[]string{"miner_id", "queue"}, nil,
+		),
+		minerBatchQueueDeadline: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "miner_batch_queue_deadline_epoch"),
+			"return earliest epoch by which a sector of the precommit or commit batch queue must land on chain",
+			[]string{"miner_id", "queue"}, nil,
 		),
 
-		ltOptions: *opts,
+		ltOptions:     *opts,
+		mpoolTracker:  lotusinfo.NewMpoolTracker(),
+		headWatcher:   lotusinfo.NewHeadWatcher(),
+		basefeeHist:   lotusinfo.NewBasefeeHistory(opts.BasefeeWindows),
+		msgTracker:    lotusinfo.NewMessageTracker(),
+		jobTracker:    lotusinfo.NewJobTracker(),
+		sectorTracker: lotusinfo.NewSectorTracker(),
+		batchQueues:   lotusinfo.NewBatchQueueTracker(),
 	}
+
+	collector.headWatcher.OnApply(collector.basefeeHist.Apply)
+	collector.headWatcher.OnApply(collector.msgTracker.Apply)
+
+	return collector
 }
 
 //Each and every collector must implement the Describe function.
@@ -186,25 +533,17 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 	//Implement logic here to determine proper metric value to return to prometheus
 	//for each descriptor or call other functions that do so.
 
-	fullNodeApiInfo := collector.ltOptions.FullNodeApiInfo
-	minerApiInfo := collector.ltOptions.MinerApiInfo
 	ctx := context.Background()
 
 	// get fullApi
-	fullNodeApiInfoS := lotusinfo.ParseApiInfo(strings.TrimSpace(fullNodeApiInfo))
-	fullNodeApiHeaders := http.Header{"Authorization": []string{"Bearer " + string(fullNodeApiInfoS.Token)}}
-	var fuApi lotusapi.FullNodeStruct
-	closer01, err01 := jsonrpc.NewMergeClient(ctx, fullNodeApiInfoS.Addr, "Filecoin", []interface{}{&fuApi.Internal, &fuApi.CommonStruct.Internal}, fullNodeApiHeaders)
+	fuApi, closer01, err01 := newFullNodeClient(ctx, collector.ltOptions.FullNodeApiInfo)
 	if err01 != nil {
 		log.Fatalf("connecting with lotus failed: %s", err01)
 	}
 	defer closer01()
 
 	// get minerApi
-	minerApiInfoS := lotusinfo.ParseApiInfo(strings.TrimSpace(minerApiInfo))
-	minerApiHeaders := http.Header{"Authorization": []string{"Bearer " + string(minerApiInfoS.Token)}}
-	var miApi lotusapi.StorageMinerStruct
-	closer02, err02 := jsonrpc.NewMergeClient(context.Background(), minerApiInfoS.Addr, "Filecoin", []interface{}{&miApi.Internal, &miApi.CommonStruct.Internal}, minerApiHeaders)
+	miApi, closer02, err02 := newMinerClient(ctx, collector.ltOptions.MinerApiInfo)
 	if err02 != nil {
 		log.Fatalf("connecting with lotus-miner failed: %s", err02)
 	}
@@ -220,6 +559,8 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 		log.Fatal(err)
 	}
 
+	collector.headWatcher.SetMiner(minerId)
+
 	// get chainHead
 	chainTipSetKey, err := lotusinfo.GetTipsetKey(ctx, fuApi)
 	if err != nil {
@@ -253,23 +594,65 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	// get local wallet
-	walletList := []string{ownerADDR, minerInfo.WorkerAddr, minerInfo.Control0Addr}
+	walletList := lotusinfo.UniqueAddrs([]string{ownerADDR, minerInfo.WorkerAddr, minerInfo.Control0Addr})
+
+	// account gas of landed messages from our addresses
+	collector.msgTracker.SetAddresses(minerInfo.AddressRoles(ownerID, ownerADDR))
 
 	// get chain sync info
-	chainSyncStats := lotusinfo.GetChainSyncState(ctx, fuApi)
+	chainSyncStats, err := lotusinfo.GetChainSyncState(ctx, fuApi)
+	if err != nil {
+		log.Printf("get chain sync state err: %s", err)
+	}
 
 	// get chain height
 	chainHeight := lotusinfo.GetChainHeight(chainTipSetKey)
 
+	// get predicted next basefee
+	nextBasefee, nextBasefeeErr := lotusinfo.GetNextBasefee(ctx, fuApi, chainTipSetKey)
+	if nextBasefeeErr != nil {
+		log.Printf("get next basefee err: %s", nextBasefeeErr)
+	}
+
+	// get head age and expected height
+	headTimeInfo := lotusinfo.GetHeadTimeInfo(ctx, fuApi, chainTipSetKey)
+
 	// get chain basefee
 	basefee := lotusinfo.GetChainBasefee(chainTipSetKey)
 
 	// get mpool total, local msg total, local msg list
 	mpoolTotal, localMpollTotal, msgLst := lotusinfo.GetMpoolInfo(ctx, fuApi, chainTipSetKey, walletList)
 
+	// get nonce info
+	nonceInfoS := lotusinfo.GetNonceInfo(ctx, fuApi, chainTipSetKey, walletList, msgLst)
+
+	// get gas estimates for miner messages
+	gasEstimateS := lotusinfo.GetGasEstimates(ctx, fuApi, minerId, minerInfo.WorkerAddr,
+		collector.msgTracker.RecentGasLimits(), collector.ltOptions.GasLimits, chainTipSetKey)
+
 	// get miner power
 	mpRaw, mpQua, tpRaw, tpQua := lotusinfo.GetPowerList(ctx, fuApi, minerId, chainTipSetKey)
 
+	// get network economics
+	economicsInfo, economicsErr := lotusinfo.GetEconomicsInfo(ctx, fuApi, chainTipSetKey)
+	if economicsErr != nil {
+		log.Printf("get network economics err: %s", economicsErr)
+	}
+
+	// get per-sector economics, without break-even when a seal gas estimate is missing
+	var sectorEconomicsS []lotusinfo.SectorEconomics
+	if economicsErr == nil {
+		sealGasCost, sealGasErr := lotusinfo.SealGasCost(gasEstimateS)
+		if sealGasErr != nil {
+			log.Printf("get seal gas cost err: %s", sealGasErr)
+		}
+		sectorEconomicsS, err = lotusinfo.GetSectorEconomics(ctx, fuApi, minerId, minerInfo.SectorSize, tpQua,
+			economicsInfo.ThisEpochReward, sealGasCost, chainTipSetKey)
+		if err != nil {
+			log.Printf("get sector economics err: %s", err)
+		}
+	}
+
 	// get miner power eligibility
 	powerEligibility := lotusinfo.GetBaseInfo(ctx, fuApi, minerId, chainHeight, chainTipSetKey)
 
@@ -283,7 +666,7 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	// get worker info
-	workerGroupInfo := lotusinfo.GetWorkerInfo(ctx, miApi)
+	workerGroupInfo := lotusinfo.GetWorkerInfo(ctx, miApi, minerInfo.SectorSize)
 
 	// get miner job
 	workerJobGroupInfo := lotusinfo.GetWorkerJobs(ctx, miApi)
@@ -291,41 +674,162 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 	// get miner sched info
 	minerSchedGroupInfo := lotusinfo.GetSchedDiag(ctx, miApi)
 
+	// get sealing config
+	sealingConfig, sealingConfigErr := lotusinfo.GetSealingConfig(ctx, miApi)
+	if sealingConfigErr != nil {
+		log.Printf("get sealing config err: %s", sealingConfigErr)
+	}
+
+	// get precommit and commit batch queues, refreshed in the background by refreshBatchQueues
+	batchQueueS := collector.batchQueues.Queues()
+
+	// get sector state changes, polled in the background by refreshSectors
+	sectorStats := collector.sectorTracker.Stats()
+
 	//Write latest value for each metric in the prometheus metric channel.
 	//Note that you can pass CounterValue, GaugeValue, or UntypedValue types here.
 	ch <- prometheus.MustNewConstMetric(collector.lotusLocalTime, prometheus.GaugeValue, float64(lotusinfo.GetLocalTime()))
 	ch <- prometheus.MustNewConstMetric(collector.lotusInfo, prometheus.GaugeValue, float64(fullNodeInfo.Value), minerId, fullNodeInfo.Network, fullNodeInfo.Version)
 	ch <- prometheus.MustNewConstMetric(collector.lotusChainHeight, prometheus.GaugeValue, float64(chainHeight), minerId)
-	ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefee, prometheus.GaugeValue, float64(basefee), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefee, prometheus.GaugeValue, lotusinfo.BigToFloat(basefee), minerId)
+	collector.collectNextBasefee(ch, minerId, nextBasefee, nextBasefeeErr)
+	for _, window := range collector.basefeeHist.Windows() {
+		windowStr := strconv.FormatInt(window.Window, 10)
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefeeWindow, prometheus.GaugeValue, window.Min, minerId, windowStr, "min")
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefeeWindow, prometheus.GaugeValue, window.Avg, minerId, windowStr, "avg")
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefeeWindow, prometheus.GaugeValue, window.Max, minerId, windowStr, "max")
+	}
 
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainHeadAge, prometheus.GaugeValue, headTimeInfo.HeadAge, minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainExpectedHeight, prometheus.GaugeValue, float64(headTimeInfo.ExpectedHeight), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainHeightDrift, prometheus.GaugeValue, float64(headTimeInfo.HeightDrift), minerId)
+
+	headStats := collector.headWatcher.Stats()
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainHeadChanges, prometheus.CounterValue, float64(headStats.Applies), minerId, "apply")
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainHeadChanges, prometheus.CounterValue, float64(headStats.Reverts), minerId, "revert")
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainNullRounds, prometheus.CounterValue, float64(headStats.NullRounds), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainBlocks, prometheus.CounterValue, float64(headStats.Blocks), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainTipsetBlocks, prometheus.GaugeValue, float64(headStats.LastBlocks), minerId)
+	ch <- prometheus.MustNewConstHistogram(collector.lotusChainArrivalDelay, headStats.ArrivalDelay.Count, headStats.ArrivalDelay.Sum,
+		headStats.ArrivalDelay.Cumulative(), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainReorgs, prometheus.CounterValue, float64(headStats.Reorgs), minerId)
+	ch <- prometheus.MustNewConstHistogram(collector.lotusChainReorgDepth, headStats.ReorgDepth.Count, headStats.ReorgDepth.Sum,
+		headStats.ReorgDepth.Cumulative(), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainReorgOwnBlocks, prometheus.CounterValue, float64(headStats.RevertedOwnBlocks), minerId)
+
+	msgStats := collector.msgTracker.Stats()
+	for _, m := range msgStats.Methods {
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainMessages, prometheus.CounterValue, float64(m.Count), minerId, m.ActorType, m.Method)
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainMessageGasUsed, prometheus.CounterValue, float64(m.GasUsed), minerId, m.ActorType, m.Method)
+	}
+	for _, gas := range msgStats.AddressGas {
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletMessages, prometheus.CounterValue, float64(gas.Messages), minerId, gas.Address, gas.Role, gas.Method)
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletGasSpent, prometheus.CounterValue, lotusinfo.AttoFilToFil(gas.Burned), minerId, gas.Address, gas.Role, gas.Method, "burn")
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletGasSpent, prometheus.CounterValue, lotusinfo.AttoFilToFil(gas.Tip), minerId, gas.Address, gas.Role, gas.Method, "tip")
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletGasSpent, prometheus.CounterValue, lotusinfo.AttoFilToFil(gas.Penalty), minerId, gas.Address, gas.Role, gas.Method, "penalty")
+	}
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainTipsetGasUsed, prometheus.GaugeValue, float64(msgStats.TipsetGasUsed), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainTipsetGasLimit, prometheus.GaugeValue, float64(msgStats.TipsetGasLimit), minerId)
+
+	syncErrors := 0
 	for _, i := range chainSyncStats {
 		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncDiff, prometheus.GaugeValue, float64(i.CSDiff), minerId, i.CSWorkerID)
 		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncStatus, prometheus.GaugeValue, float64(i.CSStatus), minerId, i.CSWorkerID)
+		for _, stage := range lotusinfo.SyncStages {
+			var isStage float64
+			if stage == i.CSStage {
+				isStage = 1
+			}
+			ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncStage, prometheus.GaugeValue, isStage, minerId, i.CSWorkerID, stage)
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncHeight, prometheus.GaugeValue, float64(i.CSBaseHeight), minerId, i.CSWorkerID, "base")
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncHeight, prometheus.GaugeValue, float64(i.CSTargetHeight), minerId, i.CSWorkerID, "target")
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncHeight, prometheus.GaugeValue, float64(i.CSHeight), minerId, i.CSWorkerID, "current")
+		if !i.CSStart.IsZero() {
+			ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncStart, prometheus.GaugeValue, float64(i.CSStart.Unix()), minerId, i.CSWorkerID)
+		}
+		ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncDuration, prometheus.GaugeValue, i.CSDuration, minerId, i.CSWorkerID)
+		if i.CSStage == lotusapi.StageSyncErrored.String() {
+			syncErrors++
+		}
 	}
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncErrors, prometheus.GaugeValue, float64(syncErrors), minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainSyncErrorMsgs, prometheus.GaugeValue, float64(lotusinfo.SyncErrorMessages(chainSyncStats)), minerId)
 
 	ch <- prometheus.MustNewConstMetric(collector.lotusMpoolTotal, prometheus.GaugeValue, float64(mpoolTotal), minerId)
 	ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalTotal, prometheus.GaugeValue, float64(localMpollTotal), minerId)
-	for _, mmsg := range msgLst {
-		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalMessage, prometheus.GaugeValue, 1, minerId,
-			mmsg.Mfrom, mmsg.Mto, strconv.FormatUint(mmsg.Mnonce, 10), strconv.FormatInt(mmsg.Mvalue, 10),
-			strconv.FormatInt(mmsg.Mgaslimit, 10), strconv.FormatInt(mmsg.Mgasfeecap, 10),
-			strconv.FormatInt(mmsg.Mgaspremium, 10), strconv.FormatInt(mmsg.Mmethod, 10),
-			mmsg.Mmethodtype, mmsg.Mactortype)
-	}
-
-	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, float64(mpRaw), minerId, "miner", "RawBytePower")
-	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, float64(mpQua), minerId, "miner", "QualityAdjPower")
-	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, float64(tpRaw), minerId, "network", "RawBytePower")
-	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, float64(tpQua), minerId, "network", "QualityAdjPower")
+
+	collector.mutex.Lock()
+	collector.mpoolMsgs = msgLst
+	collector.mutex.Unlock()
+
+	msgGroups, msgAddrs := lotusinfo.GroupMpoolMsgs(msgLst)
+	for _, group := range msgGroups {
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalPending, prometheus.GaugeValue, float64(group.Count), minerId,
+			group.Mfrom, group.Mmethodtype)
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalFeeCap, prometheus.GaugeValue, lotusinfo.AttoFilToFil(group.FeeCapTotal), minerId,
+			group.Mfrom, group.Mmethodtype)
+	}
+	for _, addr := range msgAddrs {
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalNonce, prometheus.GaugeValue, float64(addr.LowestNonce), minerId, addr.Mfrom)
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalMaxFeeCap, prometheus.GaugeValue, lotusinfo.BigToFloat(addr.MaxGasFeeCap), minerId, addr.Mfrom)
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalFeeRatio, prometheus.GaugeValue,
+			lotusinfo.BigToFloat(addr.MaxGasFeeCap)/lotusinfo.BigToFloat(basefee), minerId, addr.Mfrom)
+	}
+
+	for _, stuck := range collector.mpoolTracker.Update(msgLst, chainHeight, basefee, collector.ltOptions.MpoolStuckEpochs) {
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalOldestAge, prometheus.GaugeValue, stuck.OldestAge, minerId, stuck.Mfrom)
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalStuck, prometheus.GaugeValue, float64(stuck.Stuck), minerId, stuck.Mfrom)
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolLocalStuckFee, prometheus.GaugeValue, float64(stuck.BelowBasefee), minerId, stuck.Mfrom)
+	}
+
+	for _, nonceI := range nonceInfoS {
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletNonce, prometheus.GaugeValue, float64(nonceI.ChainNonce), minerId, nonceI.Address, "chain")
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletNonce, prometheus.GaugeValue, float64(nonceI.MpoolNonce), minerId, nonceI.Address, "mpool")
+		ch <- prometheus.MustNewConstMetric(collector.lotusMpoolNonceGaps, prometheus.GaugeValue, float64(nonceI.Gaps), minerId, nonceI.Address)
+	}
+
+	for _, gasI := range gasEstimateS {
+		ch <- prometheus.MustNewConstMetric(collector.lotusGasEstimateFee, prometheus.GaugeValue, lotusinfo.AttoFilToFil(gasI.ExpectedFee), minerId, gasI.Method, "expected")
+		ch <- prometheus.MustNewConstMetric(collector.lotusGasEstimateFee, prometheus.GaugeValue, lotusinfo.AttoFilToFil(gasI.MaxFee), minerId, gasI.Method, "max")
+		ch <- prometheus.MustNewConstMetric(collector.lotusGasEstimatePremium, prometheus.GaugeValue, lotusinfo.BigToFloat(gasI.GasPremium), minerId, gasI.Method)
+		ch <- prometheus.MustNewConstMetric(collector.lotusGasEstimateLimit, prometheus.GaugeValue, float64(gasI.GasLimit), minerId, gasI.Method, gasI.LimitSource)
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, lotusinfo.BigToFloat(mpRaw), minerId, "miner", "RawBytePower")
+	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, lotusinfo.BigToFloat(mpQua), minerId, "miner", "QualityAdjPower")
+	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, lotusinfo.BigToFloat(tpRaw), minerId, "network", "RawBytePower")
+	ch <- prometheus.MustNewConstMetric(collector.lotusPower, prometheus.GaugeValue, lotusinfo.BigToFloat(tpQua), minerId, "network", "QualityAdjPower")
+
+	if economicsErr == nil {
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilVested), minerId, "vested")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilMined), minerId, "mined")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilBurnt), minerId, "burnt")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilLocked), minerId, "locked")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilCirculating), minerId, "circulating")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkSupply, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.FilReserveDisbursed), minerId, "reserve_disbursed")
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkPledge, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.TotalPledgeCollateral), minerId)
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkEpochReward, prometheus.GaugeValue, lotusinfo.AttoFilToFil(economicsInfo.ThisEpochReward), minerId)
+		ch <- prometheus.MustNewConstMetric(collector.lotusNetworkBaseline, prometheus.GaugeValue, lotusinfo.BigToFloat(economicsInfo.ThisEpochBaselinePower), minerId)
+	}
+
+	sectorSizeStr := abi.SectorSize(minerInfo.SectorSize).ShortString()
+	for _, sectorI := range sectorEconomicsS {
+		ch <- prometheus.MustNewConstMetric(collector.lotusSectorPledge, prometheus.GaugeValue, lotusinfo.AttoFilToFil(sectorI.Pledge), minerId, sectorSizeStr, sectorI.DealType)
+		ch <- prometheus.MustNewConstMetric(collector.lotusSectorDailyReward, prometheus.GaugeValue, lotusinfo.AttoFilToFil(sectorI.DailyReward), minerId, sectorSizeStr, sectorI.DealType)
+		if sectorI.HasBreakEven {
+			ch <- prometheus.MustNewConstMetric(collector.lotusSectorBreakEven, prometheus.GaugeValue, sectorI.BreakEvenDays, minerId, sectorSizeStr, sectorI.DealType)
+		}
+	}
 	ch <- prometheus.MustNewConstMetric(collector.lotusPowerEligibility, prometheus.GaugeValue, float64(powerEligibility), minerId)
 
-	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.GetWalletBalance(ctx, fuApi, minerId), minerId, minerId, minerId)
-	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.GetWalletBalance(ctx, fuApi, ownerADDR), minerId, ownerID, ownerADDR)
-	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.GetWalletBalance(ctx, fuApi, minerInfo.WorkerAddr), minerId, minerInfo.Worker, minerInfo.WorkerAddr)
-	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.GetWalletBalance(ctx, fuApi, minerInfo.Control0Addr), minerId, minerInfo.Control0, minerInfo.Control0Addr)
+	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.AttoFilToFil(lotusinfo.GetWalletBalance(ctx, fuApi, minerId)), minerId, minerId, minerId)
+	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.AttoFilToFil(lotusinfo.GetWalletBalance(ctx, fuApi, ownerADDR)), minerId, ownerID, ownerADDR)
+	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.AttoFilToFil(lotusinfo.GetWalletBalance(ctx, fuApi, minerInfo.WorkerAddr)), minerId, minerInfo.Worker, minerInfo.WorkerAddr)
+	ch <- prometheus.MustNewConstMetric(collector.lotusWalletBalance, prometheus.GaugeValue, lotusinfo.AttoFilToFil(lotusinfo.GetWalletBalance(ctx, fuApi, minerInfo.Control0Addr)), minerId, minerInfo.Control0, minerInfo.Control0Addr)
 
 	for _, lockedI := range lockedInfoS {
-		ch <- prometheus.MustNewConstMetric(collector.lotusWalletLockedBalance, prometheus.GaugeValue, float64(lockedI.Balance), minerId, minerId, lockedI.LockedType)
+		ch <- prometheus.MustNewConstMetric(collector.lotusWalletLockedBalance, prometheus.GaugeValue, lotusinfo.AttoFilToFil(lockedI.Balance), minerId, minerId, lockedI.LockedType)
 	}
 
 	ch <- prometheus.MustNewConstMetric(collector.minerInfo, prometheus.GaugeValue, 1, minerId, minerVersion, ownerID, ownerADDR,
@@ -334,34 +838,136 @@ func (collector *lotusCollector) Collect(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(collector.minerInfoSectorSize, prometheus.GaugeValue, float64(minerInfo.SectorSize), minerId)
 
 	for _, worker0 := range workerGroupInfo {
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerCpu, prometheus.GaugeValue, float64(worker0.WCpu), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerGpu, prometheus.GaugeValue, float64(worker0.WGpu), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamTotal, prometheus.GaugeValue, float64(worker0.WRamTotal), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamReserved, prometheus.GaugeValue, float64(worker0.WRamReserved), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamTasks, prometheus.GaugeValue, float64(worker0.WRamTasks), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemTotal, prometheus.GaugeValue, float64(worker0.WVmemTotal), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemReserved, prometheus.GaugeValue, float64(worker0.WVmemReseved), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemTasks, prometheus.GaugeValue, float64(worker0.WvmemTasks), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerCpuUsed, prometheus.GaugeValue, float64(worker0.WCpuUsed), minerId, worker0.WHost)
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerGpuUsed, prometheus.GaugeValue, float64(worker0.WGpuUsed), minerId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerCpu, prometheus.GaugeValue, float64(worker0.WCpu), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerGpu, prometheus.GaugeValue, float64(worker0.WGpu), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamTotal, prometheus.GaugeValue, float64(worker0.WRamTotal), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamReserved, prometheus.GaugeValue, float64(worker0.WRamReserved), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerRamTasks, prometheus.GaugeValue, float64(worker0.WRamTasks), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemTotal, prometheus.GaugeValue, float64(worker0.WVmemTotal), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemReserved, prometheus.GaugeValue, float64(worker0.WVmemReseved), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerVmemTasks, prometheus.GaugeValue, float64(worker0.WvmemTasks), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerCpuUsed, prometheus.GaugeValue, float64(worker0.WCpuUsed), minerId, worker0.WId, worker0.WHost)
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerGpuUsed, prometheus.GaugeValue, worker0.WGpuUsed, minerId, worker0.WId, worker0.WHost)
+		for i, gpuName := range worker0.WGpuNames {
+			ch <- prometheus.MustNewConstMetric(collector.minerWorkerGpuInfo, prometheus.GaugeValue, 1, minerId, worker0.WId, worker0.WHost,
+				strconv.Itoa(i), gpuName)
+		}
+		var isEnabled float64
+		if worker0.WEnabled {
+			isEnabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerEnabled, prometheus.GaugeValue, isEnabled, minerId, worker0.WId, worker0.WHost)
+		for task, res := range worker0.WResources {
+			for _, limit := range []struct {
+				resource string
+				value    float64
+			}{
+				{"min_memory", float64(res.MinMemory)},
+				{"max_memory", float64(res.MaxMemory)},
+				{"base_min_memory", float64(res.BaseMinMemory)},
+				{"gpu_utilization", res.GPUUtilization},
+				{"max_parallelism", float64(res.MaxParallelism)},
+				{"max_parallelism_gpu", float64(res.MaxParallelismGPU)},
+			} {
+				ch <- prometheus.MustNewConstMetric(collector.minerWorkerTaskResources, prometheus.GaugeValue, limit.value, minerId,
+					worker0.WId, worker0.WHost, task, limit.resource)
+			}
+		}
+	}
+
+	jobStats := collector.jobTracker.Update(workerJobGroupInfo)
+	for _, jobCount := range jobStats.Counts {
+		ch <- prometheus.MustNewConstMetric(collector.minerWorkerJobs, prometheus.GaugeValue, float64(jobCount.Count), minerId,
+			jobCount.WorkerID, jobCount.Host, jobCount.Task, jobCount.State)
+	}
+	for _, jobTask := range jobStats.Tasks {
+		ch <- prometheus.MustNewConstMetric(collector.minerJobOldest, prometheus.GaugeValue, jobTask.OldestRunning, minerId, jobTask.Task)
+		ch <- prometheus.MustNewConstHistogram(collector.minerJobDuration, jobTask.Durations.Count, jobTask.Durations.Sum,
+			jobTask.Durations.Cumulative(), minerId, jobTask.Task)
+	}
+
+	overdueJobs := lotusinfo.OverdueJobs(workerJobGroupInfo, collector.ltOptions.JobMaxDurations)
+	collector.mutex.Lock()
+	collector.overdueJobs = overdueJobs
+	collector.mutex.Unlock()
+
+	overdueCounts := map[[3]string]int{}
+	for _, job := range overdueJobs {
+		overdueCounts[[3]string{job.JworkerId, job.Jhost, job.Jtask}]++
+	}
+	for key, count := range overdueCounts {
+		ch <- prometheus.MustNewConstMetric(collector.minerJobsOverdue, prometheus.GaugeValue, float64(count), minerId, key[0], key[1], key[2])
+	}
+
+	for _, queue := range minerSchedGroupInfo.Queue {
+		ch <- prometheus.MustNewConstMetric(collector.minerSchedRequests, prometheus.GaugeValue, float64(queue.Count), minerId,
+			queue.Task, strconv.Itoa(queue.Priority))
+	}
+	for worker, count := range minerSchedGroupInfo.OpenWindows {
+		ch <- prometheus.MustNewConstMetric(collector.minerSchedOpenWindows, prometheus.GaugeValue, float64(count), minerId, worker)
+	}
+	ch <- prometheus.MustNewConstMetric(collector.minerSchedWork, prometheus.GaugeValue, float64(minerSchedGroupInfo.ReturnedWork), minerId, "returned")
+	ch <- prometheus.MustNewConstMetric(collector.minerSchedWork, prometheus.GaugeValue, float64(minerSchedGroupInfo.Waiting), minerId, "waiting")
+	ch <- prometheus.MustNewConstMetric(collector.minerSchedWork, prometheus.GaugeValue, float64(minerSchedGroupInfo.EarlyRet), minerId, "early_returned")
+	ch <- prometheus.MustNewConstMetric(collector.minerSchedWork, prometheus.GaugeValue, float64(minerSchedGroupInfo.CallToWork), minerId, "call_to_work")
+
+	if sealingConfigErr == nil {
+		ch <- prometheus.MustNewConstMetric(collector.minerSealDelay, prometheus.GaugeValue, sealingConfig.SealDelay.Seconds(), minerId)
+		ch <- prometheus.MustNewConstMetric(collector.minerExpectedSealTime, prometheus.GaugeValue, sealingConfig.ExpectedSealDuration.Seconds(), minerId)
+		for use, addrs := range sealingConfig.ControlAddrs {
+			for _, addr := range addrs {
+				ch <- prometheus.MustNewConstMetric(collector.minerControlAddress, prometheus.GaugeValue, 1, minerId, use, addr)
+			}
+		}
+		for role, disabled := range map[string]bool{"owner": sealingConfig.DisableOwnerFallback, "worker": sealingConfig.DisableWorkerFallback} {
+			var isDisabled float64
+			if disabled {
+				isDisabled = 1
+			}
+			ch <- prometheus.MustNewConstMetric(collector.minerFallbackDisabled, prometheus.GaugeValue, isDisabled, minerId, role)
+		}
 	}
 
-	for _, workerJob0 := range workerJobGroupInfo {
-		// {"miner_id", "job_id", "worker_host", "task", "sector_id", "job_start_time", "run_wait"}
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerJob, prometheus.GaugeValue, float64(workerJob0.JjobStartEpoch),
-			minerId, workerJob0.JjobId, workerJob0.Jhost, workerJob0.Jtask, workerJob0.Jsector, workerJob0.JjobStartTime,
-			workerJob0.JrunWait)
+	for _, batchQueue := range batchQueueS {
+		ch <- prometheus.MustNewConstMetric(collector.minerBatchQueueSectors, prometheus.GaugeValue, float64(batchQueue.Sectors), minerId, batchQueue.Queue)
+		ch <- prometheus.MustNewConstMetric(collector.minerBatchQueueOldest, prometheus.GaugeValue, batchQueue.OldestAge(time.Now()), minerId, batchQueue.Queue)
+		if batchQueue.Deadline > 0 {
+			ch <- prometheus.MustNewConstMetric(collector.minerBatchQueueDeadline, prometheus.GaugeValue, float64(batchQueue.Deadline), minerId, batchQueue.Queue)
+		}
 	}
 
-	for _, minerSched0 := range minerSchedGroupInfo {
-		ch <- prometheus.MustNewConstMetric(collector.minerWorkerJob, prometheus.GaugeValue, 1, minerId, "", "",
-			minerSched0.Task, minerSched0.Sector, "", "99")
+	for _, sectorState := range sectorStats.States {
+		ch <- prometheus.MustNewConstMetric(collector.minerSectorStateEntered, prometheus.CounterValue, float64(sectorState.Entered), minerId, sectorState.State)
+		ch <- prometheus.MustNewConstSummary(collector.minerSectorStateDuration, sectorState.Left, sectorState.Seconds, nil, minerId, sectorState.State)
+	}
+	for _, sectorStage := range sectorStats.Stages {
+		ch <- prometheus.MustNewConstHistogram(collector.minerSectorStageDuration, sectorStage.Durations.Count, sectorStage.Durations.Sum,
+			sectorStage.Durations.Cumulative(), minerId, sectorStage.Stage)
 	}
 }
 
+// collectNextBasefee emits the predicted next basefee, or nothing when it could not be computed.
+func (collector *lotusCollector) collectNextBasefee(ch chan<- prometheus.Metric, minerId string, nextBasefee types.BigInt, err error) {
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(collector.lotusChainBasefeeNext, prometheus.GaugeValue, lotusinfo.BigToFloat(nextBasefee), minerId)
+}
+
 // Register registers the volume metrics
 func Register(options *LotusOpt) {
 	collector := newLotusCollector(options)
 	prometheus.MustRegister(version.NewCollector("lotus_exporter"))
 	prometheus.MustRegister(collector)
+	prometheus.MustRegister(newNetworkCollector(options))
+
+	go collector.watchHead(context.Background())
+	go collector.refreshSectors(context.Background())
+	go collector.refreshBatchQueues(context.Background())
+
+	http.HandleFunc("/mpool/local", collector.mpoolLocalHandler)
+	http.HandleFunc("/debug/sched", collector.schedDiagHandler)
+	if options.JobDetails {
+		http.HandleFunc("/jobs/overdue", collector.overdueJobsHandler)
+	}
 }
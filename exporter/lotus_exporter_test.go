@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollectNextBasefee(t *testing.T) {
+	collector := newLotusCollector(&LotusOpt{})
+
+	ch := make(chan prometheus.Metric, 1)
+	collector.collectNextBasefee(ch, "f01000", types.EmptyInt, errors.New("get block messages failed"))
+	if len(ch) != 0 {
+		t.Fatalf("next basefee emitted although it could not be computed")
+	}
+
+	collector.collectNextBasefee(ch, "f01000", types.NewInt(150), nil)
+	if len(ch) != 1 {
+		t.Fatalf("expected the next basefee metric, got %d metrics", len(ch))
+	}
+	var m dto.Metric
+	if err := (<-ch).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetGauge().GetValue(); got != 150 {
+		t.Errorf("next basefee %v, want 150", got)
+	}
+}
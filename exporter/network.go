@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spark8899/lotus_exporter/lotusinfo"
+)
+
+// networkCollector exports the libp2p view of the daemon and the miner.
+type networkCollector struct {
+	netPeers        *prometheus.Desc
+	netBandwidth    *prometheus.Desc
+	netProtoBw      *prometheus.Desc
+	netReachability *prometheus.Desc
+	netPubsubScore  *prometheus.Desc
+
+	ltOptions LotusOpt
+
+	// the miner id labels the daemon metrics too, so they can still be
+	// exported with the last known id while the miner is unreachable
+	mutex   sync.Mutex
+	minerId string
+}
+
+func newNetworkCollector(opts *LotusOpt) *networkCollector {
+	return &networkCollector{
+		netPeers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "net_peers"),
+			"return number of connected libp2p peers",
+			[]string{"miner_id", "endpoint"}, nil,
+		),
+		netBandwidth: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "net_bandwidth_bytes_total"),
+			"return total libp2p bandwidth in bytes",
+			[]string{"miner_id", "endpoint", "direction"}, nil,
+		),
+		netProtoBw: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "net_protocol_bandwidth_bytes_total"),
+			"return total libp2p bandwidth in bytes per protocol",
+			[]string{"miner_id", "endpoint", "protocol", "direction"}, nil,
+		),
+		netReachability: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "net_reachability"),
+			"return 1 for the current AutoNAT reachability and 0 for the others",
+			[]string{"miner_id", "endpoint", "reachability"}, nil,
+		),
+		netPubsubScore: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "net_pubsub_score"),
+			"return summary of the gossipsub scores the daemon gives its peers, quantile 0 is the min, 0.5 the median and 1 the max",
+			[]string{"miner_id"}, nil,
+		),
+
+		ltOptions: *opts,
+	}
+}
+
+func (collector *networkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.netPeers
+	ch <- collector.netBandwidth
+	ch <- collector.netProtoBw
+	ch <- collector.netReachability
+	ch <- collector.netPubsubScore
+}
+
+func (collector *networkCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	endpoints := map[string]lotusapi.Net{}
+
+	fuApi, closer01, err := newFullNodeClient(ctx, collector.ltOptions.FullNodeApiInfo)
+	if err != nil {
+		log.Printf("connecting with lotus failed: %s", err)
+	} else {
+		defer closer01()
+		endpoints["daemon"] = &fuApi
+	}
+
+	// a miner failure only drops the miner endpoint metrics
+	miApi, closer02, err := newMinerClient(ctx, collector.ltOptions.MinerApiInfo)
+	if err != nil {
+		log.Printf("connecting with lotus-miner failed: %s", err)
+	} else {
+		defer closer02()
+		if minerAddr, err := miApi.ActorAddress(ctx); err != nil {
+			log.Printf("get miner id err: %s", err)
+		} else {
+			collector.mutex.Lock()
+			collector.minerId = minerAddr.String()
+			collector.mutex.Unlock()
+			endpoints["miner"] = &miApi
+		}
+	}
+
+	collector.mutex.Lock()
+	minerId := collector.minerId
+	collector.mutex.Unlock()
+	if minerId == "" {
+		log.Printf("miner id unknown, skipping network metrics")
+		return
+	}
+
+	for endpoint, na := range endpoints {
+		netInfo, err := lotusinfo.GetNetInfo(ctx, na)
+		if err != nil {
+			log.Printf("get %s net info err: %s", endpoint, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(collector.netPeers, prometheus.GaugeValue, float64(netInfo.Peers), minerId, endpoint)
+		ch <- prometheus.MustNewConstMetric(collector.netBandwidth, prometheus.CounterValue, float64(netInfo.TotalIn), minerId, endpoint, "in")
+		ch <- prometheus.MustNewConstMetric(collector.netBandwidth, prometheus.CounterValue, float64(netInfo.TotalOut), minerId, endpoint, "out")
+		for _, proto := range netInfo.Protocols {
+			ch <- prometheus.MustNewConstMetric(collector.netProtoBw, prometheus.CounterValue, float64(proto.TotalIn), minerId, endpoint, proto.Protocol, "in")
+			ch <- prometheus.MustNewConstMetric(collector.netProtoBw, prometheus.CounterValue, float64(proto.TotalOut), minerId, endpoint, proto.Protocol, "out")
+		}
+		for _, reachability := range lotusinfo.NetReachabilities {
+			var isReachability float64
+			if reachability == netInfo.Reachability {
+				isReachability = 1
+			}
+			ch <- prometheus.MustNewConstMetric(collector.netReachability, prometheus.GaugeValue, isReachability, minerId, endpoint, reachability)
+		}
+	}
+
+	if daemon, ok := endpoints["daemon"]; ok {
+		scores, err := lotusinfo.GetPubsubScores(ctx, daemon)
+		if err != nil {
+			log.Printf("get pubsub scores err: %s", err)
+		} else if scores.Peers > 0 {
+			ch <- prometheus.MustNewConstSummary(collector.netPubsubScore, uint64(scores.Peers), scores.Sum,
+				map[float64]float64{0: scores.Min, 0.5: scores.Median, 1: scores.Max}, minerId)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// watchHead keeps a ChainNotify subscription open for the head watcher,
+// reconnecting to the daemon whenever the subscription drops.
+func (collector *lotusCollector) watchHead(ctx context.Context) {
+	for {
+		fuApi, closer, err := newFullNodeClient(ctx, collector.ltOptions.FullNodeApiInfo)
+		if err != nil {
+			log.Printf("connecting with lotus for chain notify failed: %s", err)
+		} else {
+			err = collector.headWatcher.Watch(ctx, fuApi)
+			closer()
+			log.Printf("chain notify stopped: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// sectorRefreshInterval is the pause between two sector state refreshes.
+const sectorRefreshInterval = time.Minute
+
+// refreshSectors polls the sector states for the sector tracker outside of the
+// scrapes, which only read its counters.
+func (collector *lotusCollector) refreshSectors(ctx context.Context) {
+	for {
+		miApi, closer, err := newMinerClient(ctx, collector.ltOptions.MinerApiInfo)
+		if err != nil {
+			log.Printf("connecting with lotus-miner for sector states failed: %s", err)
+		} else {
+			if err := collector.sectorTracker.Update(ctx, miApi); err != nil {
+				log.Printf("get sector states err: %s", err)
+			}
+			closer()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sectorRefreshInterval):
+		}
+	}
+}
+
+// batchQueueRefreshInterval is the pause between two batch queue refreshes.
+const batchQueueRefreshInterval = time.Minute
+
+// refreshBatchQueues looks up the precommit and commit batch queues outside of
+// the scrapes, which only read the last result.
+func (collector *lotusCollector) refreshBatchQueues(ctx context.Context) {
+	for {
+		collector.refreshBatchQueuesOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(batchQueueRefreshInterval):
+		}
+	}
+}
+
+func (collector *lotusCollector) refreshBatchQueuesOnce(ctx context.Context) {
+	fuApi, closer01, err := newFullNodeClient(ctx, collector.ltOptions.FullNodeApiInfo)
+	if err != nil {
+		log.Printf("connecting with lotus for batch queues failed: %s", err)
+		return
+	}
+	defer closer01()
+
+	miApi, closer02, err := newMinerClient(ctx, collector.ltOptions.MinerApiInfo)
+	if err != nil {
+		log.Printf("connecting with lotus-miner for batch queues failed: %s", err)
+		return
+	}
+	defer closer02()
+
+	if err := collector.batchQueues.Update(ctx, fuApi, miApi); err != nil {
+		log.Printf("get batch queues err: %s", err)
+	}
+}
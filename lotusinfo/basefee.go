@@ -0,0 +1,145 @@
+package lotusinfo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+)
+
+type BasefeeWindow struct {
+	Window int64
+	Min    float64
+	Avg    float64
+	Max    float64
+}
+
+type basefeeSample struct {
+	height  abi.ChainEpoch
+	basefee float64
+}
+
+// BasefeeHistory keeps the parent basefee of applied tipsets for the largest
+// configured window, so rolling min/avg/max can be reported per window.
+type BasefeeHistory struct {
+	mutex   sync.Mutex
+	windows []int64
+	samples []basefeeSample
+}
+
+func NewBasefeeHistory(windows []int64) *BasefeeHistory {
+	return &BasefeeHistory{windows: windows}
+}
+
+// Apply records the basefee of an applied tipset. It is a HeadHandler.
+func (h *BasefeeHistory) Apply(ctx context.Context, fu lotusapi.FullNodeStruct, ts *types.TipSet) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// a reorg re-applies heights we already have
+	for len(h.samples) > 0 && h.samples[len(h.samples)-1].height >= ts.Height() {
+		h.samples = h.samples[:len(h.samples)-1]
+	}
+	h.samples = append(h.samples, basefeeSample{ts.Height(), BigToFloat(GetChainBasefee(ts))})
+
+	var maxWindow int64
+	for _, w := range h.windows {
+		if w > maxWindow {
+			maxWindow = w
+		}
+	}
+	keep := 0
+	for keep < len(h.samples) && int64(ts.Height()-h.samples[keep].height) >= maxWindow {
+		keep++
+	}
+	h.samples = h.samples[keep:]
+}
+
+// Windows returns the basefee statistics in attoFIL over the last epochs of each
+// window, leaving out windows without samples.
+func (h *BasefeeHistory) Windows() []BasefeeWindow {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.samples) == 0 {
+		return nil
+	}
+	head := h.samples[len(h.samples)-1].height
+
+	var reWindows []BasefeeWindow
+	for _, w := range h.windows {
+		stat := BasefeeWindow{Window: w}
+		var sum float64
+		var count int
+		for _, s := range h.samples {
+			if int64(head-s.height) >= w {
+				continue
+			}
+			if count == 0 || s.basefee < stat.Min {
+				stat.Min = s.basefee
+			}
+			if s.basefee > stat.Max {
+				stat.Max = s.basefee
+			}
+			sum += s.basefee
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		stat.Avg = sum / float64(count)
+		reWindows = append(reWindows, stat)
+	}
+
+	return reWindows
+}
+
+// GetNextBasefee predicts the basefee of the next tipset from the gas limit of
+// the unique messages in the head tipset, as lotus does in ComputeNextBaseFee.
+func GetNextBasefee(ctx context.Context, fu lotusapi.FullNodeStruct, chainTipSetKey *types.TipSet) (types.BigInt, error) {
+	var gasLimitUsed int64
+	seen := map[cid.Cid]struct{}{}
+	for _, blk := range chainTipSetKey.Blocks() {
+		msgs, err := fu.ChainGetBlockMessages(ctx, blk.Cid())
+		if err != nil {
+			return types.EmptyInt, err
+		}
+		for _, m := range msgs.BlsMessages {
+			if _, ok := seen[m.Cid()]; !ok {
+				seen[m.Cid()] = struct{}{}
+				gasLimitUsed += m.GasLimit
+			}
+		}
+		for _, m := range msgs.SecpkMessages {
+			if _, ok := seen[m.Cid()]; !ok {
+				seen[m.Cid()] = struct{}{}
+				gasLimitUsed += m.Message.GasLimit
+			}
+		}
+	}
+
+	delta := gasLimitUsed/int64(len(chainTipSetKey.Blocks())) - build.BlockGasTarget
+	// cap change at 12.5% (BaseFeeMaxChangeDenom) by capping delta
+	if delta > build.BlockGasTarget {
+		delta = build.BlockGasTarget
+	}
+	if delta < -build.BlockGasTarget {
+		delta = -build.BlockGasTarget
+	}
+
+	baseFee := GetChainBasefee(chainTipSetKey)
+	change := big.Mul(baseFee, big.NewInt(delta))
+	change = big.Div(change, big.NewInt(build.BlockGasTarget))
+	change = big.Div(change, big.NewInt(build.BaseFeeMaxChangeDenom))
+
+	nextBaseFee := big.Add(baseFee, change)
+	if big.Cmp(nextBaseFee, big.NewInt(build.MinimumBaseFee)) < 0 {
+		nextBaseFee = big.NewInt(build.MinimumBaseFee)
+	}
+	return nextBaseFee, nil
+}
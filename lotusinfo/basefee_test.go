@@ -0,0 +1,35 @@
+package lotusinfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/ipfs/go-cid"
+)
+
+func TestGetNextBasefeeError(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	fu.Internal.ChainGetBlockMessages = func(ctx context.Context, c cid.Cid) (*lotusapi.BlockMessages, error) {
+		return nil, errors.New("block messages not found")
+	}
+
+	ts := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	if _, err := GetNextBasefee(context.Background(), fu, ts); err == nil {
+		t.Fatal("expected an error when the block messages cannot be fetched")
+	}
+}
+
+func TestBasefeeHistoryEmptyWindow(t *testing.T) {
+	h := NewBasefeeHistory([]int64{0, 2})
+	h.samples = []basefeeSample{{10, 100}, {11, 300}}
+
+	windows := h.Windows()
+	if len(windows) != 1 || windows[0].Window != 2 {
+		t.Fatalf("expected only the window with samples, got %+v", windows)
+	}
+	if windows[0].Min != 100 || windows[0].Avg != 200 || windows[0].Max != 300 {
+		t.Errorf("unexpected window statistics %+v", windows[0])
+	}
+}
@@ -0,0 +1,174 @@
+package lotusinfo
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// BatchQueueInfo describes the sectors waiting in the precommit or commit batch
+// queue of the miner. OldestQueued is when the oldest queued sector entered the
+// queue, zero when it is not known. Deadline is the earliest epoch by which one
+// of the queued sectors must land on chain, or 0 when it is not known.
+type BatchQueueInfo struct {
+	Queue        string
+	Sectors      int
+	OldestQueued time.Time
+	Deadline     abi.ChainEpoch
+}
+
+// OldestAge returns the age in seconds of the oldest queued sector at now.
+func (q BatchQueueInfo) OldestAge(now time.Time) float64 {
+	if q.OldestQueued.IsZero() {
+		return 0
+	}
+	return now.Sub(q.OldestQueued).Seconds()
+}
+
+// BatchQueueTracker keeps the batch queues of the last refresh, so scrapes do
+// not wait for the per-sector calls of large aggregate queues.
+type BatchQueueTracker struct {
+	mutex  sync.Mutex
+	queues []BatchQueueInfo
+}
+
+func NewBatchQueueTracker() *BatchQueueTracker {
+	return &BatchQueueTracker{}
+}
+
+// Update refreshes the batch queues of the miner at the current head. It is
+// meant to run in the background, one call at a time.
+func (t *BatchQueueTracker) Update(ctx context.Context, fu lotusapi.FullNodeStruct, mi lotusapi.StorageMinerStruct) error {
+	minerAddr, err := mi.ActorAddress(ctx)
+	if err != nil {
+		return err
+	}
+	head, err := fu.ChainHead(ctx)
+	if err != nil {
+		return err
+	}
+	minerInfo, err := fu.StateMinerInfo(ctx, minerAddr, head.Key())
+	if err != nil {
+		return err
+	}
+
+	queues, err := GetBatchQueues(ctx, fu, mi, minerAddr.String(), uint64(minerInfo.SectorSize), head)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.queues = queues
+	t.mutex.Unlock()
+	return nil
+}
+
+// Queues returns the batch queues of the last successful refresh.
+func (t *BatchQueueTracker) Queues() []BatchQueueInfo {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return append([]BatchQueueInfo(nil), t.queues...)
+}
+
+// GetBatchQueues returns the precommit and commit batch queues. A sector enters a
+// queue with the SectorPreCommitBatch or SectorSubmitCommitAggregate event; its
+// precommit must land before its ticket is older than MaxPreCommitRandomnessLookback
+// and its commit within MaxProveCommitDuration of the precommit epoch. The sectors
+// are looked up with at most sectorStatusWorkers calls at a time.
+func GetBatchQueues(ctx context.Context, fu lotusapi.FullNodeStruct, mi lotusapi.StorageMinerStruct, minerId string, sectorSize uint64,
+	chainTipSetKey *types.TipSet) ([]BatchQueueInfo, error) {
+	addr, err := address.NewFromString(minerId)
+	if err != nil {
+		return nil, err
+	}
+
+	preCommitPending, err := mi.SectorPreCommitPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	commitPending, err := mi.SectorCommitPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nv, err := fu.StateNetworkVersion(ctx, chainTipSetKey.Key())
+	if err != nil {
+		return nil, err
+	}
+	actorsVersion, err := actors.VersionForNetwork(nv)
+	if err != nil {
+		return nil, err
+	}
+	sealProof, err := SealProofFromSectorSize(sectorSize)
+	if err != nil {
+		return nil, err
+	}
+	maxProveCommitDuration, err := policy.GetMaxProveCommitDuration(actorsVersion, sealProof)
+	if err != nil {
+		return nil, err
+	}
+
+	preCommitNums := make([]abi.SectorNumber, len(preCommitPending))
+	for i, sector := range preCommitPending {
+		preCommitNums[i] = sector.Number
+	}
+	preCommitQueue := BatchQueueInfo{Queue: "precommit", Sectors: len(preCommitPending)}
+	for _, status := range pollSectorsStatus(ctx, mi, preCommitNums) {
+		preCommitQueue.observe(status.info.Log, "SectorPreCommitBatch", status.info.Ticket.Epoch+policy.MaxPreCommitRandomnessLookback)
+	}
+
+	commitNums := make([]abi.SectorNumber, len(commitPending))
+	for i, sector := range commitPending {
+		commitNums[i] = sector.Number
+	}
+	commitStatuses := pollSectorsStatus(ctx, mi, commitNums)
+	deadlines := make([]abi.ChainEpoch, len(commitStatuses))
+	parallel(len(commitStatuses), sectorStatusWorkers, func(i int) {
+		sectorNum := commitStatuses[i].num
+		preCommitInfo, err := fu.StateSectorPreCommitInfo(ctx, addr, sectorNum, chainTipSetKey.Key())
+		if err != nil {
+			log.Printf("get sector %d precommit info: %s", sectorNum, err)
+			return
+		}
+		deadlines[i] = preCommitInfo.PreCommitEpoch + maxProveCommitDuration
+	})
+	commitQueue := BatchQueueInfo{Queue: "commit", Sectors: len(commitPending)}
+	for i, status := range commitStatuses {
+		commitQueue.observe(status.info.Log, "SectorSubmitCommitAggregate", deadlines[i])
+	}
+
+	return []BatchQueueInfo{preCommitQueue, commitQueue}, nil
+}
+
+// observe accounts one queued sector: the time of the last event that put it in
+// the queue, falling back to its last log entry, and its deadline if known.
+func (q *BatchQueueInfo) observe(sectorLog []lotusapi.SectorLog, queuedEvent string, deadline abi.ChainEpoch) {
+	var queuedAt uint64
+	for _, entry := range sectorLog {
+		if entry.Kind == "event;sealing."+queuedEvent {
+			queuedAt = entry.Timestamp
+		}
+	}
+	if queuedAt == 0 && len(sectorLog) > 0 {
+		queuedAt = sectorLog[len(sectorLog)-1].Timestamp
+	}
+	if queuedAt > 0 {
+		if queued := time.Unix(int64(queuedAt), 0); q.OldestQueued.IsZero() || queued.Before(q.OldestQueued) {
+			q.OldestQueued = queued
+		}
+	}
+
+	if deadline > 0 && (q.Deadline == 0 || deadline < q.Deadline) {
+		q.Deadline = deadline
+	}
+}
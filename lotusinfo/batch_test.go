@@ -0,0 +1,144 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestBatchQueueObserve(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		sectors   [][]lotusapi.SectorLog
+		deadlines []abi.ChainEpoch
+		oldest    int64
+		deadline  abi.ChainEpoch
+	}{
+		{
+			name: "queued event",
+			sectors: [][]lotusapi.SectorLog{{
+				sealingEvent("SectorPreCommit2", 100),
+				sealingEvent("SectorPreCommitBatch", 110),
+				{Kind: "error;xerrors.wrapError", Timestamp: 150},
+			}},
+			deadlines: []abi.ChainEpoch{5000},
+			oldest:    110,
+			deadline:  5000,
+		},
+		{
+			name: "last queued event after a retry",
+			sectors: [][]lotusapi.SectorLog{{
+				sealingEvent("SectorPreCommitBatch", 110),
+				sealingEvent("SectorChainPreCommitFailed", 120),
+				sealingEvent("SectorRetryPreCommit", 130),
+				sealingEvent("SectorPreCommitBatch", 140),
+			}},
+			deadlines: []abi.ChainEpoch{5000},
+			oldest:    140,
+			deadline:  5000,
+		},
+		{
+			name:      "last log entry without queued event",
+			sectors:   [][]lotusapi.SectorLog{{sealingEvent("SectorPreCommit2", 100), sealingEvent("SectorRestart", 200)}},
+			deadlines: []abi.ChainEpoch{0},
+			oldest:    200,
+		},
+		{
+			name: "oldest sector and earliest known deadline",
+			sectors: [][]lotusapi.SectorLog{
+				{sealingEvent("SectorPreCommitBatch", 300)},
+				{sealingEvent("SectorPreCommitBatch", 200)},
+				{sealingEvent("SectorPreCommitBatch", 400)},
+			},
+			deadlines: []abi.ChainEpoch{6000, 0, 5500},
+			oldest:    200,
+			deadline:  5500,
+		},
+		{
+			name:      "no log",
+			sectors:   [][]lotusapi.SectorLog{nil},
+			deadlines: []abi.ChainEpoch{0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := BatchQueueInfo{Queue: "precommit", Sectors: len(tc.sectors)}
+			for i, sectorLog := range tc.sectors {
+				q.observe(sectorLog, "SectorPreCommitBatch", tc.deadlines[i])
+			}
+
+			var oldest int64
+			if !q.OldestQueued.IsZero() {
+				oldest = q.OldestQueued.Unix()
+			}
+			if oldest != tc.oldest {
+				t.Errorf("oldest queued at %d, want %d", oldest, tc.oldest)
+			}
+			if q.Deadline != tc.deadline {
+				t.Errorf("deadline %d, want %d", q.Deadline, tc.deadline)
+			}
+			if tc.oldest > 0 {
+				if age := q.OldestAge(time.Unix(tc.oldest+60, 0)); age != 60 {
+					t.Errorf("oldest age %v, want 60", age)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBatchQueues(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	fu.Internal.StateNetworkVersion = func(ctx context.Context, tsk types.TipSetKey) (network.Version, error) {
+		return network.Version15, nil
+	}
+	fu.Internal.StateSectorPreCommitInfo = func(ctx context.Context, addr address.Address, n abi.SectorNumber, tsk types.TipSetKey) (miner.SectorPreCommitOnChainInfo, error) {
+		return miner.SectorPreCommitOnChainInfo{PreCommitEpoch: abi.ChainEpoch(1000 + n)}, nil
+	}
+
+	var mi lotusapi.StorageMinerStruct
+	mi.Internal.SectorPreCommitPending = func(ctx context.Context) ([]abi.SectorID, error) {
+		return []abi.SectorID{{Miner: 1000, Number: 1}}, nil
+	}
+	mi.Internal.SectorCommitPending = func(ctx context.Context) ([]abi.SectorID, error) {
+		var pending []abi.SectorID
+		for n := abi.SectorNumber(10); n < 50; n++ {
+			pending = append(pending, abi.SectorID{Miner: 1000, Number: n})
+		}
+		return pending, nil
+	}
+	mi.Internal.SectorsStatus = func(ctx context.Context, n abi.SectorNumber, showOnChainInfo bool) (lotusapi.SectorInfo, error) {
+		return lotusapi.SectorInfo{
+			SectorID: n,
+			Ticket:   lotusapi.SealTicket{Epoch: 900},
+			Log:      []lotusapi.SectorLog{sealingEvent("SectorSubmitCommitAggregate", uint64(100+n))},
+		}, nil
+	}
+
+	ts := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	queues, err := GetBatchQueues(context.Background(), fu, mi, "f01000", 32<<30, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queues) != 2 {
+		t.Fatalf("expected precommit and commit queues, got %+v", queues)
+	}
+
+	preCommit, commit := queues[0], queues[1]
+	if preCommit.Sectors != 1 || preCommit.Deadline != 900+policy.MaxPreCommitRandomnessLookback {
+		t.Errorf("unexpected precommit queue %+v", preCommit)
+	}
+	maxProveCommitDuration, err := policy.GetMaxProveCommitDuration(7, abi.RegisteredSealProof_StackedDrg32GiBV1_1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Sectors != 40 || commit.Deadline != 1010+maxProveCommitDuration || commit.OldestQueued.Unix() != 110 {
+		t.Errorf("unexpected commit queue %+v", commit)
+	}
+}
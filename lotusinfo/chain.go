@@ -0,0 +1,261 @@
+package lotusinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+)
+
+// head change types sent by ChainNotify
+const (
+	hcRevert = "revert"
+	hcApply  = "apply"
+)
+
+// HeadStats is a snapshot of the head changes seen through ChainNotify.
+type HeadStats struct {
+	Applies      uint64
+	Reverts      uint64
+	NullRounds   uint64
+	Blocks       uint64
+	LastBlocks   int
+	ArrivalDelay Histogram
+
+	Reorgs            uint64
+	ReorgDepth        Histogram
+	RevertedOwnBlocks uint64
+}
+
+// HeadHandler is called by the HeadWatcher for every applied or reverted tipset.
+type HeadHandler func(ctx context.Context, fu lotusapi.FullNodeStruct, ts *types.TipSet)
+
+// HeadWatcher records every head change reported by ChainNotify.
+type HeadWatcher struct {
+	mutex sync.Mutex
+	stats HeadStats
+
+	onApply  []HeadHandler
+	onRevert []HeadHandler
+
+	// blocks mined by this miner are tracked when they get reverted
+	miner address.Address
+
+	// genesis timestamp, to tell whether an applied tipset is the current epoch
+	genesisTime uint64
+}
+
+func NewHeadWatcher() *HeadWatcher {
+	return &HeadWatcher{
+		stats: HeadStats{
+			ArrivalDelay: NewHistogram([]float64{1, 2, 4, 6, 8, 10, 15, 20, 25, 30, 45, 60}),
+			ReorgDepth:   NewHistogram([]float64{1, 2, 3, 5, 10, 20, 50}),
+		},
+	}
+}
+
+// SetMiner sets the miner whose reverted blocks are counted.
+func (w *HeadWatcher) SetMiner(minerId string) {
+	addr, err := address.NewFromString(minerId)
+	if err != nil {
+		log.Printf("convert miner id err: %s", err)
+		return
+	}
+
+	w.mutex.Lock()
+	w.miner = addr
+	w.mutex.Unlock()
+}
+
+// OnApply registers a handler for applied tipsets. It must be called before Watch.
+func (w *HeadWatcher) OnApply(h HeadHandler) {
+	w.onApply = append(w.onApply, h)
+}
+
+// OnRevert registers a handler for reverted tipsets. It must be called before Watch.
+func (w *HeadWatcher) OnRevert(h HeadHandler) {
+	w.onRevert = append(w.onRevert, h)
+}
+
+// Stats returns a copy of the head statistics collected so far.
+func (w *HeadWatcher) Stats() HeadStats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	stats := w.stats
+	stats.ArrivalDelay = w.stats.ArrivalDelay.Copy()
+	stats.ReorgDepth = w.stats.ReorgDepth.Copy()
+	return stats
+}
+
+// Watch subscribes to ChainNotify and records head changes until the
+// subscription is closed or ctx is cancelled.
+func (w *HeadWatcher) Watch(ctx context.Context, fu lotusapi.FullNodeStruct) error {
+	genesis, err := fu.ChainGetGenesis(ctx)
+	if err != nil {
+		return fmt.Errorf("get genesis: %w", err)
+	}
+	w.mutex.Lock()
+	w.genesisTime = genesis.MinTimestamp()
+	w.mutex.Unlock()
+
+	notifs, err := fu.ChainNotify(ctx)
+	if err != nil {
+		return fmt.Errorf("chain notify: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case changes, ok := <-notifs:
+			if !ok {
+				return errors.New("chain notify channel closed")
+			}
+			w.headChanges(ctx, fu, changes)
+		}
+	}
+}
+
+// headChanges records one ChainNotify notification. Tipsets applied together
+// with reverts are re-applies of a head switch, not new arrivals, so their
+// arrival delay is not observed, and blocks applied again after being reverted
+// in the same notification are not counted as new blocks.
+func (w *HeadWatcher) headChanges(ctx context.Context, fu lotusapi.FullNodeStruct, changes []*lotusapi.HeadChange) {
+	revertedBlocks := make(map[cid.Cid]struct{})
+	for _, change := range changes {
+		if change.Type != hcRevert {
+			continue
+		}
+		for _, c := range change.Val.Cids() {
+			revertedBlocks[c] = struct{}{}
+		}
+	}
+
+	reverted := w.reorg(changes)
+	for _, change := range changes {
+		w.headChange(ctx, fu, change, !reverted, revertedBlocks)
+	}
+}
+
+// reorg records a reorg when a notification reverts blocks that it does not
+// apply again. ChainNotify sends the reverts and applies of one head switch
+// together; a tipset that only gains blocks, {A} -> {A,B}, is reverted and
+// applied again with its blocks kept, which is neither a reorg nor an orphaned
+// block. The reorg depth is the number of reverted tipsets that lost a block.
+// It returns whether the notification reverted any tipset.
+func (w *HeadWatcher) reorg(changes []*lotusapi.HeadChange) bool {
+	applied := make(map[cid.Cid]struct{})
+	for _, change := range changes {
+		if change.Type != hcApply {
+			continue
+		}
+		for _, c := range change.Val.Cids() {
+			applied[c] = struct{}{}
+		}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	reverted := false
+	depth := 0
+	for _, change := range changes {
+		if change.Type != hcRevert {
+			continue
+		}
+		reverted = true
+		orphaned := false
+		for _, blk := range change.Val.Blocks() {
+			if _, ok := applied[blk.Cid()]; ok {
+				continue
+			}
+			orphaned = true
+			if blk.Miner == w.miner {
+				w.stats.RevertedOwnBlocks++
+			}
+		}
+		if orphaned {
+			depth++
+		}
+	}
+
+	if depth > 0 {
+		w.stats.Reorgs++
+		w.stats.ReorgDepth.Observe(float64(depth))
+	}
+	return reverted
+}
+
+// inSync tells whether ts is within one epoch of the epoch expected from the
+// wall clock, i.e. the watcher is not catching up after a restart or a sync.
+func (w *HeadWatcher) inSync(ts *types.TipSet, now time.Time) bool {
+	if w.genesisTime == 0 {
+		return false
+	}
+	expectedHeight := (now.Unix() - int64(w.genesisTime)) / int64(build.BlockDelaySecs)
+	return int64(ts.Height()) >= expectedHeight-1
+}
+
+// headChange records one applied or reverted tipset. An applied tipset that
+// keeps blocks of a tipset reverted in the same notification only widens it,
+// e.g. {A} -> {A,B}: only its new blocks are counted and it is not a new apply.
+func (w *HeadWatcher) headChange(ctx context.Context, fu lotusapi.FullNodeStruct, change *lotusapi.HeadChange, observeDelay bool,
+	revertedBlocks map[cid.Cid]struct{}) {
+	switch change.Type {
+	case hcApply:
+		// the tipset was scheduled to be mined at its min timestamp
+		now := time.Now()
+		delay := now.Sub(time.Unix(int64(change.Val.MinTimestamp()), 0)).Seconds()
+
+		newBlocks := 0
+		for _, c := range change.Val.Cids() {
+			if _, ok := revertedBlocks[c]; !ok {
+				newBlocks++
+			}
+		}
+		widened := newBlocks < len(change.Val.Cids())
+
+		var nullRounds uint64
+		if !widened {
+			parent, err := fu.ChainGetTipSet(ctx, change.Val.Parents())
+			if err != nil {
+				log.Printf("get parent tipset err: %s", err)
+			} else if gap := change.Val.Height() - parent.Height(); gap > 1 {
+				nullRounds = uint64(gap - 1)
+			}
+		}
+
+		w.mutex.Lock()
+		if !widened {
+			w.stats.Applies++
+		}
+		w.stats.NullRounds += nullRounds
+		w.stats.Blocks += uint64(newBlocks)
+		w.stats.LastBlocks = len(change.Val.Blocks())
+		if observeDelay && w.inSync(change.Val, now) {
+			w.stats.ArrivalDelay.Observe(delay)
+		}
+		w.mutex.Unlock()
+
+		for _, h := range w.onApply {
+			h(ctx, fu, change.Val)
+		}
+	case hcRevert:
+		w.mutex.Lock()
+		w.stats.Reverts++
+		w.mutex.Unlock()
+
+		for _, h := range w.onRevert {
+			h(ctx, fu, change.Val)
+		}
+	}
+}
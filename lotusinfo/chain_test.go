@@ -0,0 +1,109 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// testHeadWatcher returns a head watcher whose genesis makes head the current
+// epoch, and a daemon returning a tipset for any parent lookup.
+func testHeadWatcher(t *testing.T, head int64) (*HeadWatcher, lotusapi.FullNodeStruct) {
+	w := NewHeadWatcher()
+	w.genesisTime = uint64(time.Now().Unix() - head*int64(build.BlockDelaySecs))
+
+	genesis := mkTipSet(t, mkBlock(t, nil, 1000, 0))
+	var fu lotusapi.FullNodeStruct
+	fu.Internal.ChainGetTipSet = func(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+		return genesis, nil
+	}
+	return w, fu
+}
+
+// tipsetAt returns a tipset at height whose blocks are mined by the given miners.
+func tipsetAt(t *testing.T, w *HeadWatcher, height int64, nonce uint64, miners ...uint64) *types.TipSet {
+	var blks []*types.BlockHeader
+	for i, miner := range miners {
+		blk := mkBlock(t, nil, miner, nonce+uint64(i))
+		blk.Height = abi.ChainEpoch(height)
+		blk.Timestamp = w.genesisTime + uint64(height)*build.BlockDelaySecs
+		blks = append(blks, blk)
+	}
+	return mkTipSet(t, blks...)
+}
+
+func TestHeadWatcherArrivalDelayOnlyInSync(t *testing.T) {
+	const head = 1000
+	w, fu := testHeadWatcher(t, head)
+	ctx := context.Background()
+
+	// catching up after a restart: far behind the wall clock epoch
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{{Type: hcApply, Val: tipsetAt(t, w, head-50, 1, 1000)}})
+	if got := w.Stats().ArrivalDelay.Count; got != 0 {
+		t.Fatalf("observed %d arrival delays while catching up", got)
+	}
+
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{{Type: hcApply, Val: tipsetAt(t, w, head, 2, 1000)}})
+	if got := w.Stats().ArrivalDelay.Count; got != 1 {
+		t.Fatalf("expected 1 arrival delay for the current epoch, got %d", got)
+	}
+
+	// tipsets applied together with a revert are re-applies
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{
+		{Type: hcRevert, Val: tipsetAt(t, w, head, 2, 1000)},
+		{Type: hcApply, Val: tipsetAt(t, w, head, 3, 1001)},
+	})
+	stats := w.Stats()
+	if stats.ArrivalDelay.Count != 1 {
+		t.Errorf("observed arrival delay of a re-applied tipset, count %d", stats.ArrivalDelay.Count)
+	}
+	if stats.Applies != 3 {
+		t.Errorf("expected 3 applies, got %d", stats.Applies)
+	}
+}
+
+func TestHeadWatcherWideningIsNoReorg(t *testing.T) {
+	const head = 1000
+	w, fu := testHeadWatcher(t, head)
+	w.SetMiner("f01000")
+	ctx := context.Background()
+
+	// our block A is joined by block B of another miner at the same height
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{{Type: hcApply, Val: tipsetAt(t, w, head, 1, 1000)}})
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{
+		{Type: hcRevert, Val: tipsetAt(t, w, head, 1, 1000)},
+		{Type: hcApply, Val: tipsetAt(t, w, head, 1, 1000, 1001)},
+	})
+	stats := w.Stats()
+	if stats.Reorgs != 0 || stats.ReorgDepth.Count != 0 {
+		t.Errorf("widened tipset counted as reorg: %d reorgs", stats.Reorgs)
+	}
+	if stats.RevertedOwnBlocks != 0 {
+		t.Errorf("kept own block counted as reverted: %d", stats.RevertedOwnBlocks)
+	}
+	if stats.Applies != 1 || stats.Blocks != 2 || stats.LastBlocks != 2 {
+		t.Errorf("widened tipset: expected 1 apply and 2 blocks, got %d applies, %d blocks, %d last blocks",
+			stats.Applies, stats.Blocks, stats.LastBlocks)
+	}
+
+	// our block A is replaced by block C of another miner
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{
+		{Type: hcRevert, Val: tipsetAt(t, w, head, 1, 1000, 1001)},
+		{Type: hcApply, Val: tipsetAt(t, w, head, 5, 1002)},
+	})
+	stats = w.Stats()
+	if stats.Reorgs != 1 || stats.ReorgDepth.Count != 1 {
+		t.Errorf("expected 1 reorg, got %d", stats.Reorgs)
+	}
+	if stats.RevertedOwnBlocks != 1 {
+		t.Errorf("expected 1 reverted own block, got %d", stats.RevertedOwnBlocks)
+	}
+	if stats.Applies != 2 || stats.Blocks != 3 {
+		t.Errorf("replacing tipset: expected 2 applies and 3 blocks, got %d applies, %d blocks", stats.Applies, stats.Blocks)
+	}
+}
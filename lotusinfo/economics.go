@@ -0,0 +1,156 @@
+package lotusinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+type EconomicsInfo struct {
+	FilVested              types.BigInt
+	FilMined               types.BigInt
+	FilBurnt               types.BigInt
+	FilLocked              types.BigInt
+	FilCirculating         types.BigInt
+	FilReserveDisbursed    types.BigInt
+	TotalPledgeCollateral  types.BigInt
+	ThisEpochReward        types.BigInt
+	ThisEpochBaselinePower types.BigInt
+}
+
+// SectorEconomics is what sealing one more sector costs and earns today.
+type SectorEconomics struct {
+	DealType      string
+	Pledge        types.BigInt
+	DailyReward   types.BigInt
+	BreakEvenDays float64
+	HasBreakEven  bool
+}
+
+// sectorEconomicsLifetime is the expiration used to price the pledge of a new sector
+const sectorEconomicsLifetime = 540 * builtin.EpochsInDay
+
+// verifiedQAMultiplier is the quality multiplier of a sector fully filled with verified deals
+const verifiedQAMultiplier = 10
+
+// GetSectorEconomics prices a new CC and a fully verified sector of sectorSize:
+// the initial pledge, the expected daily reward from its share of network QAP,
+// and the days it takes the reward to pay back sealGasCost. Pledge and reward
+// both scale linearly with QA power, so the verified sector is the CC one times 10.
+// The break-even is left out when sealGasCost is nil or the reward is zero.
+func GetSectorEconomics(ctx context.Context, fu lotusapi.FullNodeStruct, minerId string, sectorSize uint64, networkQAP abi.StoragePower,
+	epochReward types.BigInt, sealGasCost types.BigInt, chainTipSetKey *types.TipSet) ([]SectorEconomics, error) {
+	addr, err := address.NewFromString(minerId)
+	if err != nil {
+		return nil, err
+	}
+
+	sealProof, err := SealProofFromSectorSize(sectorSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pledge, err := fu.StateMinerInitialPledgeCollateral(ctx, addr, miner.SectorPreCommitInfo{
+		SealProof:  sealProof,
+		Expiration: chainTipSetKey.Height() + sectorEconomicsLifetime,
+	}, chainTipSetKey.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	if networkQAP.IsZero() {
+		return nil, fmt.Errorf("network quality adjusted power is zero")
+	}
+	dailyReward := big.Div(big.Mul(big.Mul(epochReward, big.NewIntUnsigned(sectorSize)), big.NewInt(builtin.EpochsInDay)), networkQAP)
+
+	var reSector []SectorEconomics
+	for _, sector := range []struct {
+		dealType   string
+		multiplier int64
+	}{{"cc", 1}, {"verified", verifiedQAMultiplier}} {
+		sectorReward := big.Mul(dailyReward, big.NewInt(sector.multiplier))
+		breakEven, hasBreakEven := 0.0, false
+		if !sealGasCost.Nil() && !sectorReward.IsZero() {
+			breakEven, hasBreakEven = BigToFloat(sealGasCost)/BigToFloat(sectorReward), true
+		}
+		reSector = append(reSector, SectorEconomics{
+			DealType:      sector.dealType,
+			Pledge:        big.Mul(pledge, big.NewInt(sector.multiplier)),
+			DailyReward:   sectorReward,
+			BreakEvenDays: breakEven,
+			HasBreakEven:  hasBreakEven,
+		})
+	}
+
+	return reSector, nil
+}
+
+// GetEconomicsInfo returns the circulating supply components, the network
+// pledge from the power actor and the reward actor's epoch reward and baseline.
+func GetEconomicsInfo(ctx context.Context, fu lotusapi.FullNodeStruct, chainTipSetKey *types.TipSet) (EconomicsInfo, error) {
+	supply, err := fu.StateVMCirculatingSupplyInternal(ctx, chainTipSetKey.Key())
+	if err != nil {
+		return EconomicsInfo{}, err
+	}
+
+	powerState, err := readActorStateFields(ctx, fu, power.Address, chainTipSetKey, "TotalPledgeCollateral")
+	if err != nil {
+		return EconomicsInfo{}, err
+	}
+
+	rewardState, err := readActorStateFields(ctx, fu, reward.Address, chainTipSetKey, "ThisEpochReward", "ThisEpochBaselinePower")
+	if err != nil {
+		return EconomicsInfo{}, err
+	}
+
+	return EconomicsInfo{
+		FilVested:              supply.FilVested,
+		FilMined:               supply.FilMined,
+		FilBurnt:               supply.FilBurnt,
+		FilLocked:              supply.FilLocked,
+		FilCirculating:         supply.FilCirculating,
+		FilReserveDisbursed:    supply.FilReserveDisbursed,
+		TotalPledgeCollateral:  powerState["TotalPledgeCollateral"],
+		ThisEpochReward:        rewardState["ThisEpochReward"],
+		ThisEpochBaselinePower: rewardState["ThisEpochBaselinePower"],
+	}, nil
+}
+
+// readActorStateFields reads big integer fields from the JSON state of an actor.
+func readActorStateFields(ctx context.Context, fu lotusapi.FullNodeStruct, addr address.Address, chainTipSetKey *types.TipSet, fields ...string) (map[string]types.BigInt, error) {
+	actorState, err := fu.StateReadState(ctx, addr, chainTipSetKey.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(actorState.State)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	values := map[string]types.BigInt{}
+	for _, field := range fields {
+		value, err := types.BigFromString(Strval(m[field]))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s of %s: %w", field, addr, err)
+		}
+		values[field] = value
+	}
+
+	return values, nil
+}
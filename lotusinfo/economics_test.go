@@ -0,0 +1,40 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestGetSectorEconomicsWithoutSealGasCost(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	fu.Internal.StateMinerInitialPledgeCollateral = func(ctx context.Context, addr address.Address, info miner.SectorPreCommitInfo, tsk types.TipSetKey) (types.BigInt, error) {
+		return types.NewInt(1000), nil
+	}
+	ts := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	ctx := context.Background()
+
+	sectors, err := GetSectorEconomics(ctx, fu, "f01000", 32<<30, types.NewInt(1<<50), types.NewInt(1e18), types.EmptyInt, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sector := range sectors {
+		if sector.HasBreakEven {
+			t.Errorf("%s: break-even %f reported without seal gas cost", sector.DealType, sector.BreakEvenDays)
+		}
+	}
+
+	sectors, err = GetSectorEconomics(ctx, fu, "f01000", 32<<30, types.NewInt(1<<50), types.NewInt(1e18), types.NewInt(1e15), ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sector := range sectors {
+		if !sector.HasBreakEven || sector.BreakEvenDays <= 0 {
+			t.Errorf("%s: expected a break-even, got %+v", sector.DealType, sector)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package lotusinfo
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// GasEstimateMethods are the miner messages we estimate. The gas limit is passed
+// in so lotus only estimates premium and fee cap and does not have to execute fake
+// parameters. It is the average GasLimit of recently landed messages of the method;
+// until one has landed, the static DefaultGasLimit of a typical mainnet message is used.
+var GasEstimateMethods = []struct {
+	Name            string
+	Method          abi.MethodNum
+	DefaultGasLimit int64
+}{
+	{"SubmitWindowedPoSt", miner.Methods.SubmitWindowedPoSt, 50_000_000},
+	{"PreCommitSector", miner.Methods.PreCommitSector, 25_000_000},
+	{"PreCommitSectorBatch", miner.Methods.PreCommitSectorBatch, 100_000_000},
+	{"ProveCommitSector", miner.Methods.ProveCommitSector, 70_000_000},
+	{"ProveCommitAggregate", miner.Methods.ProveCommitAggregate, 400_000_000},
+}
+
+type GasEstimateInfo struct {
+	Method      string
+	GasLimit    int64
+	LimitSource string
+	GasFeeCap   types.BigInt
+	GasPremium  types.BigInt
+	ExpectedFee types.BigInt
+	MaxFee      types.BigInt
+}
+
+// GetGasEstimates estimates the cost of the miner's critical messages sent from
+// the worker address at the current head. ExpectedFee is GasLimit * (basefee +
+// premium), MaxFee is GasLimit * GasFeeCap. The gas limit of a method comes from
+// landedLimits when messages of it have landed (LimitSource "landed"), otherwise
+// from staticLimits or the DefaultGasLimit of the method (LimitSource "static").
+func GetGasEstimates(ctx context.Context, fu lotusapi.FullNodeStruct, minerId string, fromAddr string, landedLimits map[string]int64,
+	staticLimits map[string]int64, chainTipSetKey *types.TipSet) []GasEstimateInfo {
+	to, err := address.NewFromString(minerId)
+	if err != nil {
+		log.Fatalf("convert miner id err: %s", err)
+	}
+
+	from, err := address.NewFromString(fromAddr)
+	if err != nil {
+		log.Fatalf("convert addr err: %s", err)
+	}
+
+	basefee := GetChainBasefee(chainTipSetKey)
+
+	var reGas []GasEstimateInfo
+	for _, m := range GasEstimateMethods {
+		gasLimit, limitSource := landedLimits[m.Name], "landed"
+		if gasLimit <= 0 {
+			gasLimit, limitSource = m.DefaultGasLimit, "static"
+			if limit, ok := staticLimits[m.Name]; ok {
+				gasLimit = limit
+			}
+		}
+
+		msg, err := fu.GasEstimateMessageGas(ctx, &types.Message{
+			From:       from,
+			To:         to,
+			Method:     m.Method,
+			Value:      types.NewInt(0),
+			GasLimit:   gasLimit,
+			GasFeeCap:  types.NewInt(0),
+			GasPremium: types.NewInt(0),
+		}, nil, chainTipSetKey.Key())
+		if err != nil {
+			log.Printf("estimate gas for %s err: %s", m.Name, err)
+			continue
+		}
+
+		limit := types.NewInt(uint64(msg.GasLimit))
+		reGas = append(reGas, GasEstimateInfo{
+			Method:      m.Name,
+			GasLimit:    msg.GasLimit,
+			LimitSource: limitSource,
+			GasFeeCap:   msg.GasFeeCap,
+			GasPremium:  msg.GasPremium,
+			ExpectedFee: types.BigMul(limit, types.BigAdd(basefee, msg.GasPremium)),
+			MaxFee:      types.BigMul(limit, msg.GasFeeCap),
+		})
+	}
+
+	return reGas
+}
+
+// SealGasCost is the expected fee of sealing one sector without batching:
+// one PreCommitSector and one ProveCommitSector message. It fails when either
+// estimate is missing, as the cost would be understated.
+func SealGasCost(gasEstimates []GasEstimateInfo) (types.BigInt, error) {
+	cost := big.Zero()
+	found := map[string]bool{}
+	for _, gas := range gasEstimates {
+		if gas.Method == "PreCommitSector" || gas.Method == "ProveCommitSector" {
+			cost = big.Add(cost, gas.ExpectedFee)
+			found[gas.Method] = true
+		}
+	}
+	for _, method := range []string{"PreCommitSector", "ProveCommitSector"} {
+		if !found[method] {
+			return types.EmptyInt, fmt.Errorf("no %s gas estimate", method)
+		}
+	}
+	return cost, nil
+}
+
+// GasOutputs is how the fee of a landed message was split.
+type GasOutputs struct {
+	Burned  types.BigInt
+	Tip     types.BigInt
+	Penalty types.BigInt
+}
+
+// ComputeGasOutputs splits the fee of a landed message into burned gas (base fee
+// and over-estimation burn), miner tip and miner penalty, as lotus does in
+// chain/vm ComputeGasOutputs with the network fee charged.
+func ComputeGasOutputs(gasUsed, gasLimit int64, baseFee, feeCap, gasPremium types.BigInt) GasOutputs {
+	gasUsedBig := big.NewInt(gasUsed)
+	out := GasOutputs{Burned: big.Zero(), Tip: big.Zero(), Penalty: big.Zero()}
+
+	baseFeeToPay := baseFee
+	if baseFee.GreaterThan(feeCap) {
+		baseFeeToPay = feeCap
+		out.Penalty = big.Mul(big.Sub(baseFee, feeCap), gasUsedBig)
+	}
+	out.Burned = big.Mul(baseFeeToPay, gasUsedBig)
+
+	minerTip := gasPremium
+	if big.Add(baseFeeToPay, minerTip).GreaterThan(feeCap) {
+		minerTip = big.Sub(feeCap, baseFeeToPay)
+	}
+	out.Tip = big.Mul(minerTip, big.NewInt(gasLimit))
+
+	if gasBurned := gasOverestimationBurn(gasUsed, gasLimit); gasBurned != 0 {
+		gasBurnedBig := big.NewInt(gasBurned)
+		out.Burned = big.Add(out.Burned, big.Mul(baseFeeToPay, gasBurnedBig))
+		out.Penalty = big.Add(out.Penalty, big.Mul(big.Sub(baseFee, baseFeeToPay), gasBurnedBig))
+	}
+
+	return out
+}
+
+// gasOverestimationBurn returns the gas burned for setting GasLimit too far above GasUsed.
+func gasOverestimationBurn(gasUsed, gasLimit int64) int64 {
+	if gasUsed == 0 {
+		return gasLimit
+	}
+
+	over := gasLimit - (11*gasUsed)/10
+	if over < 0 {
+		return 0
+	}
+	if over > gasUsed {
+		over = gasUsed
+	}
+
+	gasToBurn := big.Mul(big.NewInt(gasLimit-gasUsed), big.NewInt(over))
+	return big.Div(gasToBurn, big.NewInt(gasUsed)).Int64()
+}
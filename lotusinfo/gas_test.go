@@ -0,0 +1,71 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestGetGasEstimatesLimitSource(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	sentLimits := map[string]int64{}
+	fu.Internal.GasEstimateMessageGas = func(ctx context.Context, msg *types.Message, spec *lotusapi.MessageSendSpec, tsk types.TipSetKey) (*types.Message, error) {
+		sentLimits[MethodName("storageminer", msg.Method)] = msg.GasLimit
+		out := *msg
+		out.GasFeeCap = types.NewInt(200)
+		out.GasPremium = types.NewInt(100)
+		return &out, nil
+	}
+
+	ts := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	landed := map[string]int64{"SubmitWindowedPoSt": 42_000_000}
+	static := map[string]int64{"PreCommitSector": 30_000_000}
+	estimates := GetGasEstimates(context.Background(), fu, "f01000", "f01001", landed, static, ts)
+	if len(estimates) != len(GasEstimateMethods) {
+		t.Fatalf("expected %d estimates, got %d", len(GasEstimateMethods), len(estimates))
+	}
+
+	for _, gas := range estimates {
+		var wantLimit int64
+		wantSource := "static"
+		switch gas.Method {
+		case "SubmitWindowedPoSt":
+			wantLimit, wantSource = 42_000_000, "landed"
+		case "PreCommitSector":
+			wantLimit = 30_000_000
+		default:
+			for _, m := range GasEstimateMethods {
+				if m.Name == gas.Method {
+					wantLimit = m.DefaultGasLimit
+				}
+			}
+		}
+		if sentLimits[gas.Method] != wantLimit || gas.LimitSource != wantSource {
+			t.Errorf("%s: estimated with limit %d from %s, want %d from %s", gas.Method, sentLimits[gas.Method], gas.LimitSource, wantLimit, wantSource)
+		}
+		if want := types.BigMul(types.NewInt(uint64(wantLimit)), types.NewInt(200)); !gas.MaxFee.Equals(want) {
+			t.Errorf("%s: max fee %s, want %s", gas.Method, gas.MaxFee, want)
+		}
+	}
+}
+
+func TestSealGasCostMissingEstimate(t *testing.T) {
+	estimates := []GasEstimateInfo{
+		{Method: "PreCommitSector", ExpectedFee: types.NewInt(100)},
+		{Method: "SubmitWindowedPoSt", ExpectedFee: types.NewInt(10)},
+	}
+	if _, err := SealGasCost(estimates); err == nil {
+		t.Fatal("expected an error without a ProveCommitSector estimate")
+	}
+
+	estimates = append(estimates, GasEstimateInfo{Method: "ProveCommitSector", ExpectedFee: types.NewInt(300)})
+	cost, err := SealGasCost(estimates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cost.Equals(types.NewInt(400)) {
+		t.Errorf("expected seal gas cost 400, got %s", cost)
+	}
+}
@@ -0,0 +1,59 @@
+package lotusinfo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+)
+
+// mkBlock returns a block of miner on top of parents (genesis when nil); blocks
+// with a different ticketNonce get a different CID.
+func mkBlock(t *testing.T, parents *types.TipSet, miner uint64, ticketNonce uint64) *types.BlockHeader {
+	addr, err := address.NewIDAddress(miner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cid.Decode("bafyreicmaj5hhoy5mgqvamfhgexxyergw7hdeshizghodwkjg6qmpoco7i")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pcids []cid.Cid
+	var height abi.ChainEpoch
+	var timestamp uint64
+	if parents != nil {
+		pcids = parents.Cids()
+		height = parents.Height() + 1
+		timestamp = parents.MinTimestamp() + build.BlockDelaySecs
+	}
+
+	return &types.BlockHeader{
+		Miner:                 addr,
+		ElectionProof:         &types.ElectionProof{VRFProof: []byte(fmt.Sprintf("====%d=====", ticketNonce))},
+		Ticket:                &types.Ticket{VRFProof: []byte(fmt.Sprintf("====%d=====", ticketNonce))},
+		Parents:               pcids,
+		ParentMessageReceipts: c,
+		BLSAggregate:          &crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte("signature")},
+		ParentWeight:          types.NewInt(0),
+		Messages:              c,
+		Height:                height,
+		Timestamp:             timestamp,
+		ParentStateRoot:       c,
+		BlockSig:              &crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte("signature")},
+		ParentBaseFee:         types.NewInt(uint64(build.MinimumBaseFee)),
+	}
+}
+
+func mkTipSet(t *testing.T, blks ...*types.BlockHeader) *types.TipSet {
+	ts, err := types.NewTipSet(blks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
@@ -0,0 +1,39 @@
+package lotusinfo
+
+// Histogram accumulates observations into fixed buckets so they can be
+// exported as a const histogram on every scrape.
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+func NewHistogram(buckets []float64) Histogram {
+	return Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(value float64) {
+	for i, upper := range h.Buckets {
+		if value <= upper {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += value
+}
+
+// Cumulative returns the bucket counts keyed by upper bound.
+func (h Histogram) Cumulative() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(h.Buckets))
+	for i, upper := range h.Buckets {
+		buckets[upper] = h.Counts[i]
+	}
+	return buckets
+}
+
+// Copy returns a histogram that does not share bucket counts with h.
+func (h Histogram) Copy() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
@@ -0,0 +1,152 @@
+package lotusinfo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
+)
+
+// JobStateName names the RunWait value of a worker job.
+func JobStateName(runWait int) string {
+	switch {
+	case runWait == storiface.RWRunning:
+		return "running"
+	case runWait == storiface.RWPrepared:
+		return "prepared"
+	case runWait > storiface.RWPrepared:
+		return "assigned"
+	case runWait == storiface.RWRetWait:
+		return "ret_wait"
+	case runWait == storiface.RWReturned:
+		return "returned"
+	case runWait == storiface.RWRetDone:
+		return "ret_done"
+	default:
+		return "unknown"
+	}
+}
+
+type JobCount struct {
+	WorkerID string
+	Host     string
+	Task     string
+	State    string
+	Count    int
+}
+
+type JobTaskStats struct {
+	Task          string
+	OldestRunning float64
+	Durations     Histogram
+}
+
+type JobStats struct {
+	Counts []JobCount
+	Tasks  []JobTaskStats
+}
+
+type runningJob struct {
+	task  string
+	start time.Time
+}
+
+// JobTracker remembers the running worker jobs between refreshes; a running job
+// that is gone on the next refresh is counted as completed.
+type JobTracker struct {
+	mutex     sync.Mutex
+	running   map[string]runningJob
+	durations map[string]*Histogram
+}
+
+func NewJobTracker() *JobTracker {
+	return &JobTracker{
+		running:   map[string]runningJob{},
+		durations: map[string]*Histogram{},
+	}
+}
+
+var jobDurationBuckets = []float64{60, 300, 900, 1800, 3600, 2 * 3600, 4 * 3600, 6 * 3600, 8 * 3600, 12 * 3600, 24 * 3600}
+
+// Update records the current worker jobs and returns job counts per worker,
+// task and state, the oldest running job per task and the completed job durations.
+func (t *JobTracker) Update(jobs []JobInfoStruct) JobStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	running := map[string]runningJob{}
+	counts := map[[4]string]int{}
+	oldest := map[string]float64{}
+	for _, job := range jobs {
+		state := JobStateName(job.JrunWait)
+		counts[[4]string{job.JworkerId, job.Jhost, job.Jtask, state}]++
+		if job.JrunWait != storiface.RWRunning {
+			continue
+		}
+
+		running[job.JjobId] = runningJob{job.Jtask, job.Jstart}
+		if job.Jelapsed > oldest[job.Jtask] {
+			oldest[job.Jtask] = job.Jelapsed
+		}
+	}
+
+	for id, job := range t.running {
+		if _, ok := running[id]; ok {
+			continue
+		}
+		hist, ok := t.durations[job.task]
+		if !ok {
+			h := NewHistogram(jobDurationBuckets)
+			hist = &h
+			t.durations[job.task] = hist
+		}
+		hist.Observe(now.Sub(job.start).Seconds())
+	}
+	t.running = running
+
+	var stats JobStats
+	for key, count := range counts {
+		stats.Counts = append(stats.Counts, JobCount{key[0], key[1], key[2], key[3], count})
+	}
+
+	tasks := map[string]struct{}{}
+	for task := range oldest {
+		tasks[task] = struct{}{}
+	}
+	for task := range t.durations {
+		tasks[task] = struct{}{}
+	}
+	for task := range tasks {
+		taskStats := JobTaskStats{Task: task, OldestRunning: oldest[task], Durations: NewHistogram(jobDurationBuckets)}
+		if hist, ok := t.durations[task]; ok {
+			taskStats.Durations = hist.Copy()
+		}
+		stats.Tasks = append(stats.Tasks, taskStats)
+	}
+	sort.Slice(stats.Tasks, func(i, j int) bool { return stats.Tasks[i].Task < stats.Tasks[j].Task })
+
+	return stats
+}
+
+// OverdueJobs returns the running jobs that have been running longer than the
+// maximum duration configured for their task type. maxDurations is keyed by the
+// short task name (PC1, PC2, C2, ...).
+func OverdueJobs(jobs []JobInfoStruct, maxDurations map[string]time.Duration) []JobInfoStruct {
+	var overdue []JobInfoStruct
+	for _, job := range jobs {
+		if job.JrunWait != storiface.RWRunning {
+			continue
+		}
+		maxDuration, ok := maxDurations[sealtasks.TaskType(job.Jtask).Short()]
+		if !ok {
+			continue
+		}
+		if job.Jelapsed > maxDuration.Seconds() {
+			overdue = append(overdue, job)
+		}
+	}
+	return overdue
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/multiformats/go-multibase"
 	"log"
@@ -18,29 +19,66 @@ type DaemonInfo struct {
 	Value   int64
 }
 
+type HeadTimeInfo struct {
+	HeadAge        float64
+	ExpectedHeight int64
+	HeightDrift    int64
+}
+
 type ChainSyncState struct {
-	CSWorkerID string
-	CSDiff     int64
-	CSStatus   int
+	CSWorkerID     string
+	CSDiff         int64
+	CSStatus       int
+	CSStage        string
+	CSBaseHeight   int64
+	CSTargetHeight int64
+	CSHeight       int64
+	CSStart        time.Time
+	CSDuration     float64
+	CSMessage      string
 }
 
 type MpoolMsg struct {
+	Mcid        string       `json:"cid"`
+	Mfrom       string       `json:"from"`
+	Mto         string       `json:"to"`
+	Mnonce      uint64       `json:"nonce"`
+	Mvalue      types.BigInt `json:"value"`
+	Mgaslimit   int64        `json:"gas_limit"`
+	Mgasfeecap  types.BigInt `json:"gas_fee_cap"`
+	Mgaspremium types.BigInt `json:"gas_premium"`
+	Mmethod     int64        `json:"method"`
+	Mmethodtype string       `json:"method_type"`
+	Mactortype  string       `json:"to_actor_type"`
+}
+
+// MpoolMsgGroup aggregates pending local messages by sender and method type.
+type MpoolMsgGroup struct {
 	Mfrom       string
-	Mto         string
-	Mnonce      uint64
-	Mvalue      int64
-	Mgaslimit   int64
-	Mgasfeecap  int64
-	Mgaspremium int64
-	Mmethod     int64
 	Mmethodtype string
-	Mactortype  string
+	Count       int
+	FeeCapTotal types.BigInt
+}
+
+// MpoolAddrInfo summarises the pending local messages of one sender.
+type MpoolAddrInfo struct {
+	Mfrom        string
+	LowestNonce  uint64
+	MaxGasFeeCap types.BigInt
+}
+
+// NonceInfo compares the on-chain and mpool nonce of a sending address.
+type NonceInfo struct {
+	Address    string
+	ChainNonce uint64
+	MpoolNonce uint64
+	Gaps       int
 }
 
 type WalletInfo struct {
 	Name    string
 	Address string
-	Balance uint64
+	Balance types.BigInt
 }
 
 func GetLocalTime() (localTime int64) {
@@ -84,8 +122,8 @@ func GetInfo(ctx context.Context, fu lotusapi.FullNodeStruct, chainHead *types.T
 	return daemonInfo, nil
 }
 
-func GetChainBasefee(chainTipSetKey *types.TipSet) int64 {
-	return chainTipSetKey.Blocks()[0].ParentBaseFee.Int64()
+func GetChainBasefee(chainTipSetKey *types.TipSet) types.BigInt {
+	return chainTipSetKey.Blocks()[0].ParentBaseFee
 }
 
 func GetChainHeight(chainTipSetKey *types.TipSet) int64 {
@@ -98,28 +136,101 @@ func GetChainHeight(chainTipSetKey *types.TipSet) int64 {
 	return height
 }
 
-func GetChainSyncState(ctx context.Context, fu lotusapi.FullNodeStruct) []ChainSyncState {
+// GetHeadTimeInfo returns the age of the head tipset, the epoch expected from
+// the genesis time and block delay, and how far the head lags behind it.
+func GetHeadTimeInfo(ctx context.Context, fu lotusapi.FullNodeStruct, chainTipSetKey *types.TipSet) HeadTimeInfo {
+	genesis, err := fu.ChainGetGenesis(ctx)
+	if err != nil {
+		log.Fatalf("get genesis err: %s", err)
+	}
+
+	now := time.Now()
+	headAge := now.Sub(time.Unix(int64(chainTipSetKey.MinTimestamp()), 0)).Seconds()
+	expectedHeight := (now.Unix() - int64(genesis.MinTimestamp())) / int64(build.BlockDelaySecs)
+
+	return HeadTimeInfo{
+		HeadAge:        headAge,
+		ExpectedHeight: expectedHeight,
+		HeightDrift:    expectedHeight - int64(chainTipSetKey.Height()),
+	}
+}
+
+// SyncStages are the sync stage names reported by lotus, in stage order.
+var SyncStages = []string{
+	lotusapi.StageIdle.String(),
+	lotusapi.StageHeaders.String(),
+	lotusapi.StagePersistHeaders.String(),
+	lotusapi.StageMessages.String(),
+	lotusapi.StageFetchingMessages.String(),
+	lotusapi.StageSyncComplete.String(),
+	lotusapi.StageSyncErrored.String(),
+}
+
+func GetChainSyncState(ctx context.Context, fu lotusapi.FullNodeStruct) ([]ChainSyncState, error) {
 	syncStat, err := fu.SyncState(ctx)
 	if err != nil {
-		// return err
+		return nil, err
 	}
 	var reSS []ChainSyncState
 
-	for i, ss := range syncStat.ActiveSyncs {
-		var heightDiff int64
+	for _, ss := range syncStat.ActiveSyncs {
+		var heightDiff, baseHeight, targetHeight int64
 
-		if int64(ss.Target.Height()) >= int64(ss.Base.Height()) {
-			heightDiff = int64(ss.Target.Height()) - int64(ss.Base.Height())
+		if ss.Base != nil {
+			baseHeight = int64(ss.Base.Height())
+		}
+		if ss.Target != nil {
+			targetHeight = int64(ss.Target.Height())
+		}
+		if targetHeight >= baseHeight {
+			heightDiff = targetHeight - baseHeight
 		} else {
 			heightDiff = -1
 		}
-		reSS = append(reSS, ChainSyncState{strconv.Itoa(i), heightDiff, int(ss.Stage)})
+
+		var duration float64
+		if !ss.Start.IsZero() {
+			end := ss.End
+			if end.IsZero() {
+				end = time.Now()
+			}
+			duration = end.Sub(ss.Start).Seconds()
+		}
+
+		if ss.Stage == lotusapi.StageSyncErrored {
+			log.Printf("sync worker %d errored: %s", ss.WorkerID, ss.Message)
+		}
+
+		reSS = append(reSS, ChainSyncState{
+			CSWorkerID:     strconv.FormatUint(ss.WorkerID, 10),
+			CSDiff:         heightDiff,
+			CSStatus:       int(ss.Stage),
+			CSStage:        ss.Stage.String(),
+			CSBaseHeight:   baseHeight,
+			CSTargetHeight: targetHeight,
+			CSHeight:       int64(ss.Height),
+			CSStart:        ss.Start,
+			CSDuration:     duration,
+			CSMessage:      ss.Message,
+		})
 	}
 
-	return reSS
+	return reSS, nil
+}
+
+// SyncErrorMessages returns the number of distinct error messages of the sync
+// workers in the error stage; workers failing on the same tipset report the same one.
+func SyncErrorMessages(syncStates []ChainSyncState) int {
+	messages := map[string]struct{}{}
+	for _, ss := range syncStates {
+		if ss.CSStage == lotusapi.StageSyncErrored.String() {
+			messages[ss.CSMessage] = struct{}{}
+		}
+	}
+	return len(messages)
 }
 
-func GetPowerList(ctx context.Context, fu lotusapi.FullNodeStruct, minerId string, chainTipSetKey *types.TipSet) (mpRW int64, mpQw int64, tpRw int64, tpQw int64) {
+func GetPowerList(ctx context.Context, fu lotusapi.FullNodeStruct, minerId string, chainTipSetKey *types.TipSet) (mpRW, mpQw, tpRw, tpQw abi.StoragePower) {
 	addr, err := address.NewFromString(minerId)
 	if err != nil {
 		log.Fatalf("convert miner id err: %s", err)
@@ -133,7 +244,7 @@ func GetPowerList(ctx context.Context, fu lotusapi.FullNodeStruct, minerId strin
 	mp := power.MinerPower
 	tp := power.TotalPower
 
-	return mp.RawBytePower.Int64(), mp.QualityAdjPower.Int64(), tp.QualityAdjPower.Int64(), tp.RawBytePower.Int64()
+	return mp.RawBytePower, mp.QualityAdjPower, tp.RawBytePower, tp.QualityAdjPower
 }
 
 func GetBaseInfo(ctx context.Context, fu lotusapi.FullNodeStruct, minerId string, chainHeight int64, chainTipSetKey *types.TipSet) (eligibility int) {
@@ -177,35 +288,143 @@ func GetMpoolInfo(ctx context.Context, fu lotusapi.FullNodeStruct, chainTipSetKe
 			}
 		}
 
-		actor, err01 := fu.StateGetActor(ctx, msg.Message.To, chainTipSetKey.Key())
-		if err01 != nil {
-			log.Fatalf("get actor err: %s", err01)
-		}
-
-		_, actorType, err01 := multibase.Decode(actor.Code.String())
+		actorType, err01 := GetActorType(ctx, fu, msg.Message.To, chainTipSetKey.Key())
 		if err01 != nil {
 			log.Fatalf("get actor type err: %s", err01)
 		}
 
-		messageType := MethodMessageType[string(actorType[10:])][msg.Message.Method]
+		messageType := MethodName(actorType, msg.Message.Method)
 
 		msgList = append(msgList, MpoolMsg{
+			msg.Cid().String(),
 			msg.Message.From.String(),
 			msg.Message.To.String(),
 			msg.Message.Nonce,
-			msg.Message.Value.Int64(),
+			msg.Message.Value,
 			msg.Message.GasLimit,
-			msg.Message.GasFeeCap.Int64(),
-			msg.Message.GasPremium.Int64(),
+			msg.Message.GasFeeCap,
+			msg.Message.GasPremium,
 			int64(msg.Message.Method),
 			messageType,
-			string(actorType[10:])})
+			actorType})
 		//	msg.Message.From
 	}
 
 	return len(mpoolPending), len(msgList), msgList
 }
 
+// GroupMpoolMsgs aggregates pending local messages per sender and method type,
+// and per sender alone, so they can be exported without per-message labels.
+func GroupMpoolMsgs(msgList []MpoolMsg) (groups []MpoolMsgGroup, addrs []MpoolAddrInfo) {
+	groupIdx := map[[2]string]int{}
+	addrIdx := map[string]int{}
+	for _, msg := range msgList {
+		// the fee cap total is the most the message can cost: GasFeeCap * GasLimit
+		maxFee := types.BigMul(msg.Mgasfeecap, types.NewInt(uint64(msg.Mgaslimit)))
+
+		key := [2]string{msg.Mfrom, msg.Mmethodtype}
+		if i, ok := groupIdx[key]; ok {
+			groups[i].Count++
+			groups[i].FeeCapTotal = types.BigAdd(groups[i].FeeCapTotal, maxFee)
+		} else {
+			groupIdx[key] = len(groups)
+			groups = append(groups, MpoolMsgGroup{msg.Mfrom, msg.Mmethodtype, 1, maxFee})
+		}
+
+		if i, ok := addrIdx[msg.Mfrom]; ok {
+			if msg.Mnonce < addrs[i].LowestNonce {
+				addrs[i].LowestNonce = msg.Mnonce
+			}
+			if msg.Mgasfeecap.GreaterThan(addrs[i].MaxGasFeeCap) {
+				addrs[i].MaxGasFeeCap = msg.Mgasfeecap
+			}
+		} else {
+			addrIdx[msg.Mfrom] = len(addrs)
+			addrs = append(addrs, MpoolAddrInfo{msg.Mfrom, msg.Mnonce, msg.Mgasfeecap})
+		}
+	}
+
+	return groups, addrs
+}
+
+// GetNonceInfo returns, for each address, the on-chain actor nonce, the next
+// nonce the mpool would assign and the number of nonces missing between the
+// on-chain nonce and the highest pending nonce. A gap blocks every later message.
+func GetNonceInfo(ctx context.Context, fu lotusapi.FullNodeStruct, chainTipSetKey *types.TipSet, addrList []string, msgList []MpoolMsg) []NonceInfo {
+	var reNonce []NonceInfo
+	for _, a := range UniqueAddrs(addrList) {
+		addr, err := address.NewFromString(a)
+		if err != nil {
+			log.Printf("convert addr err: %s", err)
+			continue
+		}
+
+		actor, err := fu.StateGetActor(ctx, addr, chainTipSetKey.Key())
+		if err != nil {
+			log.Printf("get actor %s err: %s", a, err)
+			continue
+		}
+
+		mpoolNonce, err := fu.MpoolGetNonce(ctx, addr)
+		if err != nil {
+			log.Printf("get mpool nonce %s err: %s", a, err)
+			continue
+		}
+
+		pending := map[uint64]struct{}{}
+		maxNonce := actor.Nonce
+		for _, msg := range msgList {
+			if msg.Mfrom != a || msg.Mnonce < actor.Nonce {
+				continue
+			}
+			pending[msg.Mnonce] = struct{}{}
+			if msg.Mnonce > maxNonce {
+				maxNonce = msg.Mnonce
+			}
+		}
+
+		gaps := 0
+		if len(pending) > 0 {
+			gaps = int(maxNonce-actor.Nonce+1) - len(pending)
+		}
+
+		reNonce = append(reNonce, NonceInfo{a, actor.Nonce, mpoolNonce, gaps})
+	}
+
+	return reNonce
+}
+
+// UniqueAddrs returns addrList without empty and repeated addresses, keeping the
+// first occurrence, as the owner, worker and control addresses may be the same.
+func UniqueAddrs(addrList []string) []string {
+	seen := map[string]struct{}{}
+	var reAddrs []string
+	for _, a := range addrList {
+		if _, ok := seen[a]; ok || a == "" {
+			continue
+		}
+		seen[a] = struct{}{}
+		reAddrs = append(reAddrs, a)
+	}
+	return reAddrs
+}
+
+// GetActorType returns the builtin actor type (e.g. storageminer) of addr.
+func GetActorType(ctx context.Context, fu lotusapi.FullNodeStruct, addr address.Address, tsk types.TipSetKey) (string, error) {
+	actor, err := fu.StateGetActor(ctx, addr, tsk)
+	if err != nil {
+		return "", err
+	}
+
+	// the code cid is an identity hash of the actor name like fil/7/storageminer
+	_, actorType, err := multibase.Decode(actor.Code.String())
+	if err != nil {
+		return "", err
+	}
+
+	return string(actorType[10:]), nil
+}
+
 func GetWalletlist(ctx context.Context, fu lotusapi.FullNodeStruct) (mpoolTotal int) {
 	walletList, err := fu.WalletList(ctx)
 	if err != nil {
@@ -215,7 +434,7 @@ func GetWalletlist(ctx context.Context, fu lotusapi.FullNodeStruct) (mpoolTotal
 	return len(walletList)
 }
 
-func GetWalletBalance(ctx context.Context, fu lotusapi.FullNodeStruct, addrStg string) (balance float64) {
+func GetWalletBalance(ctx context.Context, fu lotusapi.FullNodeStruct, addrStg string) (balance types.BigInt) {
 	addr, err := address.NewFromString(addrStg)
 	if err != nil {
 		log.Fatalf("convert addr id err: %s", err)
@@ -226,10 +445,5 @@ func GetWalletBalance(ctx context.Context, fu lotusapi.FullNodeStruct, addrStg s
 		log.Fatalf("get blance err: %s", err)
 	}
 
-	balanceFl, err := strconv.ParseFloat(types.FIL(addrBalance).Unitless(), 64)
-	if err != nil {
-		log.Fatalf("convert blance float err: %s", err)
-	}
-
-	return balanceFl
+	return addrBalance
 }
@@ -0,0 +1,63 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestGetNonceInfoOwnerIsWorker(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	actorCalls := 0
+	fu.Internal.StateGetActor = func(ctx context.Context, addr address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+		actorCalls++
+		return &types.Actor{Nonce: 7}, nil
+	}
+	fu.Internal.MpoolGetNonce = func(ctx context.Context, addr address.Address) (uint64, error) {
+		return 9, nil
+	}
+
+	owner := "f3vvmn62lofvhjd2ugzca6sof2j2ubwok6cj4xxbfzz4yuxfkgobpihhd2thlanmsh3w2ptld2gqkn2jvlss4a"
+	control := "f1abjxfbp274xpdqcpuaykwkfb43omjotacm2p3za"
+	msgList := []MpoolMsg{
+		{Mfrom: owner, Mnonce: 7},
+		{Mfrom: owner, Mnonce: 8},
+	}
+
+	nonces := GetNonceInfo(context.Background(), fu, nil, []string{owner, owner, control}, msgList)
+	if len(nonces) != 2 {
+		t.Fatalf("expected one nonce info per address, got %d: %+v", len(nonces), nonces)
+	}
+	if actorCalls != 2 {
+		t.Errorf("expected 2 StateGetActor calls, got %d", actorCalls)
+	}
+	if nonces[0].Address != owner || nonces[1].Address != control {
+		t.Errorf("unexpected addresses %s, %s", nonces[0].Address, nonces[1].Address)
+	}
+	if nonces[0].ChainNonce != 7 || nonces[0].MpoolNonce != 9 || nonces[0].Gaps != 0 {
+		t.Errorf("unexpected owner nonce info %+v", nonces[0])
+	}
+}
+
+func TestUniqueAddrs(t *testing.T) {
+	got := UniqueAddrs([]string{"f01000", "f01000", "", "f01001", "f01000"})
+	if len(got) != 2 || got[0] != "f01000" || got[1] != "f01001" {
+		t.Errorf("unexpected unique addresses %v", got)
+	}
+}
+
+func TestSyncErrorMessages(t *testing.T) {
+	errored := lotusapi.StageSyncErrored.String()
+	states := []ChainSyncState{
+		{CSWorkerID: "1", CSStage: errored, CSMessage: "bad tipset"},
+		{CSWorkerID: "2", CSStage: errored, CSMessage: "bad tipset"},
+		{CSWorkerID: "3", CSStage: errored, CSMessage: "timeout"},
+		{CSWorkerID: "4", CSStage: lotusapi.StageSyncComplete.String(), CSMessage: "stale"},
+	}
+	if got := SyncErrorMessages(states); got != 2 {
+		t.Errorf("expected 2 distinct error messages, got %d", got)
+	}
+}
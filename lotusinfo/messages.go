@@ -0,0 +1,283 @@
+package lotusinfo
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// actor types are looked up once per address; the cache is dropped when it grows past this
+const actorTypeCacheSize = 100000
+
+// gasLimitSamples is the number of landed messages per method the recent gas limit is averaged over
+const gasLimitSamples = 100
+
+type MessageMethodStats struct {
+	ActorType string
+	Method    string
+	Count     uint64
+	GasUsed   uint64
+}
+
+// AddressGasStats is the gas spent by one of our sending addresses on one method, in attoFIL.
+type AddressGasStats struct {
+	Address  string
+	Role     string
+	Method   string
+	Messages uint64
+	Burned   types.BigInt
+	Tip      types.BigInt
+	Penalty  types.BigInt
+}
+
+type MessageStats struct {
+	Methods        []MessageMethodStats
+	AddressGas     []AddressGasStats
+	TipsetGasUsed  int64
+	TipsetGasLimit int64
+}
+
+type methodKey struct {
+	actorType string
+	method    string
+}
+
+type addressGasKey struct {
+	address string
+	role    string
+	method  string
+}
+
+// MessageTracker decodes the messages executed in every applied tipset and
+// accumulates counts and gas used by actor type and method. The messages of a
+// parent tipset are accounted once, however often tipsets on top of it are
+// applied: a widening head {A} -> {A,B} or a sibling swapped in by a reorg
+// applies another tipset with the same parent.
+type MessageTracker struct {
+	mutex          sync.Mutex
+	methods        map[methodKey]*MessageMethodStats
+	tipsetGasUsed  int64
+	tipsetGasLimit int64
+
+	// height of the parent tipsets accounted within the last ChainFinality epochs
+	accounted map[types.TipSetKey]abi.ChainEpoch
+
+	addresses  map[address.Address]string
+	addressGas map[addressGasKey]*AddressGasStats
+
+	actorTypes map[address.Address]string
+
+	// GasLimit of the last landed messages of each GasEstimateMethods method
+	gasLimits map[string][]int64
+}
+
+func NewMessageTracker() *MessageTracker {
+	return &MessageTracker{
+		methods:    map[methodKey]*MessageMethodStats{},
+		accounted:  map[types.TipSetKey]abi.ChainEpoch{},
+		addresses:  map[address.Address]string{},
+		addressGas: map[addressGasKey]*AddressGasStats{},
+		actorTypes: map[address.Address]string{},
+		gasLimits:  map[string][]int64{},
+	}
+}
+
+// SetAddresses sets the sending addresses (owner, worker, control) whose gas
+// spending is accounted, mapped to their role. Both ID and key addresses should be given.
+func (t *MessageTracker) SetAddresses(addrRoles map[string]string) {
+	addresses := map[address.Address]string{}
+	for a, role := range addrRoles {
+		addr, err := address.NewFromString(a)
+		if err != nil {
+			continue
+		}
+		addresses[addr] = role
+	}
+
+	t.mutex.Lock()
+	t.addresses = addresses
+	t.mutex.Unlock()
+}
+
+// Apply accounts the parent messages of an applied tipset, unless that parent
+// was already accounted. It is a HeadHandler.
+func (t *MessageTracker) Apply(ctx context.Context, fu lotusapi.FullNodeStruct, ts *types.TipSet) {
+	t.mutex.Lock()
+	_, done := t.accounted[ts.Parents()]
+	t.mutex.Unlock()
+	if done {
+		return
+	}
+
+	parent, err := fu.ChainGetTipSet(ctx, ts.Parents())
+	if err != nil {
+		log.Printf("get parent tipset err: %s", err)
+		return
+	}
+
+	blockCid := ts.Blocks()[0].Cid()
+	msgs, err := fu.ChainGetParentMessages(ctx, blockCid)
+	if err != nil {
+		log.Printf("get parent messages err: %s", err)
+		return
+	}
+	receipts, err := fu.ChainGetParentReceipts(ctx, blockCid)
+	if err != nil {
+		log.Printf("get parent receipts err: %s", err)
+		return
+	}
+	if len(msgs) != len(receipts) {
+		log.Printf("parent messages and receipts mismatch: %d != %d", len(msgs), len(receipts))
+		return
+	}
+
+	t.mutex.Lock()
+	addresses := t.addresses
+	t.mutex.Unlock()
+
+	// the parent messages were executed with the basefee recorded in this tipset
+	baseFee := GetChainBasefee(ts)
+
+	var gasUsed int64
+	counted := map[methodKey]MessageMethodStats{}
+	var ownGas []AddressGasStats
+	landedLimits := map[string][]int64{}
+	for i, msg := range msgs {
+		actorType := t.actorType(ctx, fu, msg.Message.To, parent.Key())
+		key := methodKey{actorType, MethodName(actorType, msg.Message.Method)}
+
+		stats := counted[key]
+		stats.Count++
+		stats.GasUsed += uint64(receipts[i].GasUsed)
+		counted[key] = stats
+		gasUsed += receipts[i].GasUsed
+
+		if actorType == "storageminer" && receipts[i].ExitCode.IsSuccess() && isGasEstimateMethod(key.method) {
+			landedLimits[key.method] = append(landedLimits[key.method], msg.Message.GasLimit)
+		}
+
+		if role, ok := addresses[msg.Message.From]; ok {
+			out := ComputeGasOutputs(receipts[i].GasUsed, msg.Message.GasLimit, baseFee, msg.Message.GasFeeCap, msg.Message.GasPremium)
+			ownGas = append(ownGas, AddressGasStats{msg.Message.From.String(), role, key.method, 1, out.Burned, out.Tip, out.Penalty})
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.accounted[parent.Key()] = parent.Height()
+	for key, height := range t.accounted {
+		if height < parent.Height()-policy.ChainFinality {
+			delete(t.accounted, key)
+		}
+	}
+
+	for _, gas := range ownGas {
+		gasKey := addressGasKey{gas.Address, gas.Role, gas.Method}
+		total, ok := t.addressGas[gasKey]
+		if !ok {
+			total = &AddressGasStats{Address: gas.Address, Role: gas.Role, Method: gas.Method, Burned: big.Zero(), Tip: big.Zero(), Penalty: big.Zero()}
+			t.addressGas[gasKey] = total
+		}
+		total.Messages += gas.Messages
+		total.Burned = big.Add(total.Burned, gas.Burned)
+		total.Tip = big.Add(total.Tip, gas.Tip)
+		total.Penalty = big.Add(total.Penalty, gas.Penalty)
+	}
+
+	for method, limits := range landedLimits {
+		limits = append(t.gasLimits[method], limits...)
+		if len(limits) > gasLimitSamples {
+			limits = limits[len(limits)-gasLimitSamples:]
+		}
+		t.gasLimits[method] = limits
+	}
+
+	for key, stats := range counted {
+		total, ok := t.methods[key]
+		if !ok {
+			total = &MessageMethodStats{ActorType: key.actorType, Method: key.method}
+			t.methods[key] = total
+		}
+		total.Count += stats.Count
+		total.GasUsed += stats.GasUsed
+	}
+	t.tipsetGasUsed = gasUsed
+	t.tipsetGasLimit = build.BlockGasLimit * int64(len(parent.Blocks()))
+}
+
+func (t *MessageTracker) actorType(ctx context.Context, fu lotusapi.FullNodeStruct, addr address.Address, tsk types.TipSetKey) string {
+	t.mutex.Lock()
+	actorType, ok := t.actorTypes[addr]
+	t.mutex.Unlock()
+	if ok {
+		return actorType
+	}
+
+	actorType, err := GetActorType(ctx, fu, addr, tsk)
+	if err != nil {
+		// e.g. a send creating a new account actor
+		return "unknown"
+	}
+
+	t.mutex.Lock()
+	if len(t.actorTypes) >= actorTypeCacheSize {
+		t.actorTypes = map[address.Address]string{}
+	}
+	t.actorTypes[addr] = actorType
+	t.mutex.Unlock()
+
+	return actorType
+}
+
+// RecentGasLimits returns the average GasLimit of the last landed messages per
+// GasEstimateMethods method, for the methods seen since the exporter started.
+func (t *MessageTracker) RecentGasLimits() map[string]int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	recent := map[string]int64{}
+	for method, limits := range t.gasLimits {
+		var sum int64
+		for _, limit := range limits {
+			sum += limit
+		}
+		recent[method] = sum / int64(len(limits))
+	}
+	return recent
+}
+
+func isGasEstimateMethod(method string) bool {
+	for _, m := range GasEstimateMethods {
+		if m.Name == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the accumulated message statistics and the gas of the last applied tipset.
+func (t *MessageTracker) Stats() MessageStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stats := MessageStats{
+		TipsetGasUsed:  t.tipsetGasUsed,
+		TipsetGasLimit: t.tipsetGasLimit,
+	}
+	for _, m := range t.methods {
+		stats.Methods = append(stats.Methods, *m)
+	}
+	for _, gas := range t.addressGas {
+		stats.AddressGas = append(stats.AddressGas, *gas)
+	}
+	return stats
+}
@@ -0,0 +1,146 @@
+package lotusinfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	builtin7 "github.com/filecoin-project/specs-actors/v7/actors/builtin"
+	"github.com/ipfs/go-cid"
+)
+
+// testParentMessages returns a daemon whose parent tipset is parent, executing
+// one message per sender to the miner actor f01000 with the given method.
+func testParentMessages(t *testing.T, parent *types.TipSet, method abi.MethodNum, senders ...address.Address) lotusapi.FullNodeStruct {
+	to, err := address.NewIDAddress(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs []lotusapi.Message
+	var receipts []*types.MessageReceipt
+	for _, from := range senders {
+		msgs = append(msgs, lotusapi.Message{Message: &types.Message{
+			From:       from,
+			To:         to,
+			Method:     method,
+			GasLimit:   1000,
+			GasFeeCap:  types.NewInt(1000),
+			GasPremium: types.NewInt(1),
+		}})
+		receipts = append(receipts, &types.MessageReceipt{GasUsed: 1000})
+	}
+
+	var fu lotusapi.FullNodeStruct
+	fu.Internal.ChainGetTipSet = func(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+		return parent, nil
+	}
+	fu.Internal.ChainGetParentMessages = func(ctx context.Context, c cid.Cid) ([]lotusapi.Message, error) {
+		return msgs, nil
+	}
+	fu.Internal.ChainGetParentReceipts = func(ctx context.Context, c cid.Cid) ([]*types.MessageReceipt, error) {
+		return receipts, nil
+	}
+	fu.Internal.StateGetActor = func(ctx context.Context, addr address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+		if addr == to {
+			return &types.Actor{Code: builtin7.StorageMinerActorCodeID}, nil
+		}
+		return nil, errors.New("no state in test")
+	}
+	return fu
+}
+
+func TestMessageTrackerControlAddressRoles(t *testing.T) {
+	parent := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	ts := mkTipSet(t, mkBlock(t, parent, 1000, 2))
+
+	worker, _ := address.NewIDAddress(1001)
+	control1, _ := address.NewIDAddress(1003)
+	other, _ := address.NewIDAddress(2000)
+	fu := testParentMessages(t, parent, abi.MethodNum(5), worker, control1, control1, other)
+
+	tracker := NewMessageTracker()
+	tracker.SetAddresses(map[string]string{"f01001": "worker", "f01002": "control0", "f01003": "control1"})
+	tracker.Apply(context.Background(), fu, ts)
+
+	messages := map[string]uint64{}
+	for _, gas := range tracker.Stats().AddressGas {
+		messages[gas.Role] += gas.Messages
+		if gas.Burned.IsZero() {
+			t.Errorf("%s: no gas burned accounted", gas.Role)
+		}
+	}
+	if len(messages) != 2 || messages["worker"] != 1 || messages["control1"] != 2 {
+		t.Errorf("unexpected landed messages per role %v", messages)
+	}
+}
+
+func TestMessageTrackerWideningCountsParentOnce(t *testing.T) {
+	parent := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	sender, _ := address.NewIDAddress(2000)
+	fu := testParentMessages(t, parent, abi.MethodNum(5), sender, sender)
+
+	tracker := NewMessageTracker()
+	w := NewHeadWatcher()
+	w.OnApply(tracker.Apply)
+	ctx := context.Background()
+
+	blkA := mkBlock(t, parent, 1000, 2)
+	blkB := mkBlock(t, parent, 1001, 3)
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{{Type: hcApply, Val: mkTipSet(t, blkA)}})
+	before := tracker.Stats().Methods
+
+	// the head widens from {A} to {A,B}, both on top of the same parent
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{
+		{Type: hcRevert, Val: mkTipSet(t, blkA)},
+		{Type: hcApply, Val: mkTipSet(t, blkA, blkB)},
+	})
+	// a sibling at the same height is swapped in
+	w.headChanges(ctx, fu, []*lotusapi.HeadChange{
+		{Type: hcRevert, Val: mkTipSet(t, blkA, blkB)},
+		{Type: hcApply, Val: mkTipSet(t, mkBlock(t, parent, 1002, 4))},
+	})
+
+	after := tracker.Stats().Methods
+	if len(before) != 1 || before[0].Count != 2 {
+		t.Fatalf("expected 2 messages of one method, got %+v", before)
+	}
+	if len(after) != 1 || after[0] != before[0] {
+		t.Errorf("re-applied parent counted again: before %+v, after %+v", before, after)
+	}
+}
+
+func TestMessageTrackerReappliedOwnGas(t *testing.T) {
+	parent := mkTipSet(t, mkBlock(t, nil, 1000, 1))
+	worker, _ := address.NewIDAddress(1001)
+	fu := testParentMessages(t, parent, miner.Methods.SubmitWindowedPoSt, worker)
+
+	tracker := NewMessageTracker()
+	tracker.SetAddresses(map[string]string{"f01001": "worker"})
+	ctx := context.Background()
+
+	blkA := mkBlock(t, parent, 1000, 2)
+	tracker.Apply(ctx, fu, mkTipSet(t, blkA))
+	before := tracker.Stats().AddressGas
+	if len(before) != 1 || before[0].Method != "SubmitWindowedPoSt" || before[0].Messages != 1 {
+		t.Fatalf("expected one WindowPoSt of the worker, got %+v", before)
+	}
+
+	// re-applies on top of the same parent: widening and a swapped sibling
+	tracker.Apply(ctx, fu, mkTipSet(t, blkA, mkBlock(t, parent, 1002, 3)))
+	tracker.Apply(ctx, fu, mkTipSet(t, mkBlock(t, parent, 1003, 4)))
+
+	after := tracker.Stats().AddressGas
+	if len(after) != 1 || after[0].Messages != 1 || !after[0].Burned.Equals(before[0].Burned) ||
+		!after[0].Tip.Equals(before[0].Tip) || !after[0].Penalty.Equals(before[0].Penalty) {
+		t.Errorf("re-applied parent counted again: before %+v, after %+v", before, after)
+	}
+	if samples := len(tracker.gasLimits["SubmitWindowedPoSt"]); samples != 1 {
+		t.Errorf("expected 1 landed gas limit sample, got %d", samples)
+	}
+}
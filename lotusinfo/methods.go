@@ -1,7 +1,10 @@
 package lotusinfo
 
+import "github.com/filecoin-project/go-state-types/abi"
+
+// Method tables are indexed by method number minus one; method 0 is a plain send.
 var (
-	MethodAccount  = []string{"Constructor", "Constructor"}
+	MethodAccount  = []string{"Constructor", "PubkeyAddress"}
 	MethodInit     = []string{"Constructor", "Exec"}
 	MethodCron     = []string{"Constructor", "EpochTick"}
 	MethodReward   = []string{"Constructor", "AwardBlockReward", "ThisEpochReward", "UpdateNetworkKPI"}
@@ -16,11 +19,25 @@ var (
 		"SubmitWindowedPoSt", "PreCommitSector", "ProveCommitSector", "ExtendSectorExpiration", "TerminateSectors",
 		"DeclareFaults", "DeclareFaultsRecovered", "OnDeferredCronEvent", "CheckSectorProven", "ApplyRewards",
 		"ReportConsensusFault", "WithdrawBalance", "ConfirmSectorProofsValid", "ChangeMultiaddrs", "CompactPartitions",
-		"CompactSectorNumbers", "ConfirmUpdateWorkerKey", "RepayDebt", "ChangeOwnerAddress", "DisputeWindowedPoSt"}
+		"CompactSectorNumbers", "ConfirmUpdateWorkerKey", "RepayDebt", "ChangeOwnerAddress", "DisputeWindowedPoSt",
+		"PreCommitSectorBatch", "ProveCommitAggregate", "ProveReplicaUpdates"}
 	MethodVerifiedRegistry = []string{"Constructor", "AddVerifier", "RemoveVerifier", "AddVerifiedClient", "UseBytes",
-		"RestoreBytes"}
+		"RestoreBytes", "RemoveVerifiedClientDataCap"}
 	MethodMessageType = map[string][]string{"account": MethodAccount, "init": MethodInit, "cron": MethodCron,
 		"reward": MethodReward, "multisig": MethodMultisig, "paymentchannel": MethodPaymentChannel,
 		"storagemarket": MethodStorageMarket, "storagepower": MethodStoragePower, "storageminer": MethodStorageMiner,
 		"verifiedregistry": MethodVerifiedRegistry}
 )
+
+// MethodName returns the method name for a message sent to an actor of the given type.
+func MethodName(actorType string, method abi.MethodNum) string {
+	if method == 0 {
+		return "Send"
+	}
+
+	methods := MethodMessageType[actorType]
+	if int(method) > len(methods) {
+		return "Unknown"
+	}
+	return methods[method-1]
+}
@@ -3,11 +3,13 @@ package lotusinfo
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	lotusapi "github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/extern/sector-storage/sealtasks"
+	"github.com/filecoin-project/lotus/extern/sector-storage/storiface"
 	"log"
 	"strconv"
 	"time"
@@ -20,15 +22,44 @@ type MinerInfoStruct struct {
 	WorkerAddr   string
 	Control0     string
 	Control0Addr string
+	Controls     []ControlAddrInfo
 	SectorSize   uint64
 }
 
+// ControlAddrInfo is a control address of the miner as ID and account key address.
+type ControlAddrInfo struct {
+	ID   string
+	Addr string
+}
+
+// AddressRoles maps the ID and key addresses of the owner, worker and control
+// addresses to their role: owner, worker, control0, control1, ... An address
+// with several roles gets the first of them in that order.
+func (info MinerInfoStruct) AddressRoles(ownerID, ownerAddr string) map[string]string {
+	roles := map[string]string{}
+	addRole := func(role string, addrs ...string) {
+		for _, a := range addrs {
+			if _, ok := roles[a]; !ok && a != "" {
+				roles[a] = role
+			}
+		}
+	}
+
+	addRole("owner", ownerID, ownerAddr)
+	addRole("worker", info.Worker, info.WorkerAddr)
+	for i, control := range info.Controls {
+		addRole("control"+strconv.Itoa(i), control.ID, control.Addr)
+	}
+	return roles
+}
+
 type LockedInfoStruct struct {
 	LockedType string
-	Balance    float64
+	Balance    types.BigInt
 }
 
 type WorkerInfoStuct struct {
+	WId          string
 	WHost        string
 	WCpu         uint64
 	WGpu         int
@@ -39,22 +70,44 @@ type WorkerInfoStuct struct {
 	WVmemReseved int
 	WvmemTasks   uint64
 	WCpuUsed     uint64
-	WGpuUsed     int
+	WGpuUsed     float64
+	WEnabled     bool
+	WResources   map[string]storiface.Resources
+	WGpuNames    []string
 }
 
 type JobInfoStruct struct {
-	JjobId         string
-	Jsector        string
-	Jhost          string
-	Jtask          string
-	JjobStartTime  string
-	JrunWait       string
-	JjobStartEpoch int
+	JjobId    string    `json:"job_id"`
+	Jsector   string    `json:"sector"`
+	JworkerId string    `json:"worker_id"`
+	Jhost     string    `json:"worker_host"`
+	Jtask     string    `json:"task"`
+	Jstart    time.Time `json:"start"`
+	JrunWait  int       `json:"run_wait"`
+	Jelapsed  float64   `json:"elapsed_seconds"`
+}
+
+type SealingConfigInfo struct {
+	SealDelay             time.Duration
+	ExpectedSealDuration  time.Duration
+	ControlAddrs          map[string][]string
+	DisableOwnerFallback  bool
+	DisableWorkerFallback bool
+}
+
+type SchedQueueInfo struct {
+	Task     string
+	Priority int
+	Count    int
 }
 
-type SchedInfoStruct struct {
-	Sector string
-	Task   string
+type SchedDiagStats struct {
+	Queue        []SchedQueueInfo
+	OpenWindows  map[string]int
+	ReturnedWork int
+	Waiting      int
+	EarlyRet     int
+	CallToWork   int
 }
 
 type SchedDiagRequestInfo struct {
@@ -111,7 +164,6 @@ func GetMinerInfo(ctx context.Context, fu lotusapi.FullNodeStruct, minerId strin
 
 	owner := minerStats.Owner
 	worker := minerStats.Worker
-	control0 := minerStats.ControlAddresses[0]
 	sectorSize := minerStats.SectorSize.String()
 
 	sectorSizeNum, err := strconv.ParseUint(sectorSize, 10, 64)
@@ -131,9 +183,17 @@ func GetMinerInfo(ctx context.Context, fu lotusapi.FullNodeStruct, minerId strin
 		return MinerInfoStruct{}, err
 	}
 
-	control0Addr, err := fu.StateAccountKey(ctx, control0, chainTipSetKey.Key())
-	if err != nil {
-		log.Printf("get miner control0 address: %s", err)
+	var controls []ControlAddrInfo
+	for i, control := range minerStats.ControlAddresses {
+		controlAddr, err := fu.StateAccountKey(ctx, control, chainTipSetKey.Key())
+		if err != nil {
+			log.Printf("get miner control%d address: %s", i, err)
+		}
+		controls = append(controls, ControlAddrInfo{control.String(), controlAddr.String()})
+	}
+	var control0 ControlAddrInfo
+	if len(controls) > 0 {
+		control0 = controls[0]
 	}
 
 	return MinerInfoStruct{
@@ -141,8 +201,9 @@ func GetMinerInfo(ctx context.Context, fu lotusapi.FullNodeStruct, minerId strin
 		OwnerAddr:    ownerAddr.String(),
 		Worker:       worker.String(),
 		WorkerAddr:   workerAddr.String(),
-		Control0:     control0.String(),
-		Control0Addr: control0Addr.String(),
+		Control0:     control0.ID,
+		Control0Addr: control0.Addr,
+		Controls:     controls,
 		SectorSize:   sectorSizeNum,
 	}, nil
 }
@@ -179,32 +240,52 @@ func GetLockedFunds(ctx context.Context, fu lotusapi.FullNodeStruct, minerId str
 		value := Strval(m1[i])
 		valueInt, err := types.BigFromString(value)
 		if err != nil {
-			log.Fatalf("convert string to uint64: %s", err)
+			log.Fatalf("convert string to big int: %s", err)
 			return []LockedInfoStruct{}
 		}
 
-		balanceFl, err := strconv.ParseFloat(types.FIL(valueInt).Unitless(), 64)
-		if err != nil {
-			log.Fatalf("convert blance float err: %s", err)
-			return []LockedInfoStruct{}
-		}
 		lockedInfoG = append(lockedInfoG, LockedInfoStruct{
 			LockedType: i,
-			Balance:    balanceFl,
+			Balance:    valueInt,
 		})
 	}
 
 	return lockedInfoG
 }
 
-func GetWorkerInfo(ctx context.Context, mi lotusapi.StorageMinerStruct) (workers []WorkerInfoStuct) {
+// SealProofFromSectorSize returns the current seal proof type for sectors of sectorSize.
+func SealProofFromSectorSize(sectorSize uint64) (abi.RegisteredSealProof, error) {
+	switch abi.SectorSize(sectorSize) {
+	case 2 << 10:
+		return abi.RegisteredSealProof_StackedDrg2KiBV1_1, nil
+	case 8 << 20:
+		return abi.RegisteredSealProof_StackedDrg8MiBV1_1, nil
+	case 512 << 20:
+		return abi.RegisteredSealProof_StackedDrg512MiBV1_1, nil
+	case 32 << 30:
+		return abi.RegisteredSealProof_StackedDrg32GiBV1_1, nil
+	case 64 << 30:
+		return abi.RegisteredSealProof_StackedDrg64GiBV1_1, nil
+	default:
+		return 0, fmt.Errorf("unsupported sector size %s", abi.SectorSize(sectorSize).ShortString())
+	}
+}
+
+// GetWorkerInfo returns the hardware, usage and enabled state of each worker, with
+// the resource limits it is configured with per task type for sectors of sectorSize.
+func GetWorkerInfo(ctx context.Context, mi lotusapi.StorageMinerStruct, sectorSize uint64) (workers []WorkerInfoStuct) {
 	workerStats, err := mi.WorkerStats(ctx)
 	if err != nil {
 		log.Fatalf("get miner actor address: %s", err)
 	}
 
+	sealProof, sealProofErr := SealProofFromSectorSize(sectorSize)
+	if sealProofErr != nil {
+		log.Printf("get worker resources: %s", sealProofErr)
+	}
+
 	var WorkerGroups []WorkerInfoStuct
-	for _, worker := range workerStats {
+	for workerId, worker := range workerStats {
 		workerHost := worker.Info.Hostname
 		cpus := worker.Info.Resources.CPUs
 		gpus := len(worker.Info.Resources.GPUs)
@@ -225,15 +306,17 @@ func GetWorkerInfo(ctx context.Context, mi lotusapi.StorageMinerStruct) (workers
 			vmemReserved = int(vmemUsed) - int(vmemTasks)
 		}
 
-		var gpuUsed int
-		if worker.GpuUsed > 0 {
-			gpuUsed = 1
-		} else {
-			gpuUsed = 0
-		}
 		cpuUsed := worker.CpuUse
 
+		resources := map[string]storiface.Resources{}
+		if sealProofErr == nil {
+			for taskType := range storiface.ResourceTable {
+				resources[string(taskType)] = worker.Info.Resources.ResourceSpec(sealProof, taskType)
+			}
+		}
+
 		WorkerGroups = append(WorkerGroups, WorkerInfoStuct{
+			workerId.String(),
 			workerHost,
 			cpus,
 			gpus,
@@ -244,7 +327,10 @@ func GetWorkerInfo(ctx context.Context, mi lotusapi.StorageMinerStruct) (workers
 			vmemReserved,
 			vmemTasks,
 			cpuUsed,
-			gpuUsed,
+			worker.GpuUsed,
+			worker.Enabled,
+			resources,
+			worker.Info.Resources.GPUs,
 		})
 	}
 
@@ -258,35 +344,35 @@ func GetWorkerJobs(ctx context.Context, mi lotusapi.StorageMinerStruct) (jobInfo
 	}
 
 	var reJobInfo []JobInfoStruct
-	for _, jobList := range workerJobs {
+	for workerId, jobList := range workerJobs {
 		for _, job := range jobList {
 			jobId := job.ID.String()
 			sector := job.Sector.Number.String()
 			workerHost := job.Hostname
 			task := string(job.Task)
-			jobStartTime := job.Start.Format("2006-01-02T15:04:05Z07:00")
-			runWait := strconv.Itoa(job.RunWait)
-			jobStartEpoch := int(time.Now().Unix() - job.Start.Unix())
+			elapsed := time.Since(job.Start).Seconds()
 			reJobInfo = append(reJobInfo, JobInfoStruct{
 				jobId,
 				sector,
+				workerId.String(),
 				workerHost,
 				task,
-				jobStartTime,
-				runWait,
-				jobStartEpoch,
+				job.Start,
+				job.RunWait,
+				elapsed,
 			})
 		}
 	}
 	return reJobInfo
 }
 
-func GetSchedDiag(ctx context.Context, mi lotusapi.StorageMinerStruct) []SchedInfoStruct {
+// GetSchedDiag summarises the sealing scheduler state: queued requests per task
+// type and priority, open windows per worker and the manager's work tracking.
+func GetSchedDiag(ctx context.Context, mi lotusapi.StorageMinerStruct) SchedDiagStats {
 	schedDiag, err := mi.SealingSchedDiag(ctx, true)
 	if err != nil {
 		log.Fatalf("get miner sched diag: %s", err)
 	}
-	log.Printf("schedDiag: %s", schedDiag)
 
 	data1, err := json.Marshal(schedDiag)
 	if err != nil {
@@ -299,15 +385,67 @@ func GetSchedDiag(ctx context.Context, mi lotusapi.StorageMinerStruct) []SchedIn
 		log.Fatalf("convert json to map: %s", err)
 	}
 
-	var reSchedInfo []SchedInfoStruct
+	queueIdx := map[SchedQueueInfo]int{}
+	reSched := SchedDiagStats{
+		OpenWindows:  map[string]int{},
+		ReturnedWork: len(schedParseInfo.ReturnedWork),
+		Waiting:      len(schedParseInfo.Waiting),
+		EarlyRet:     len(schedParseInfo.EarlyRet),
+		CallToWork:   len(schedParseInfo.CallToWork),
+	}
 	for _, req := range schedParseInfo.SchedInfo.Requests {
-		sector := req.Sector.Number.String()
-		task := string(req.TaskType)
-		reSchedInfo = append(reSchedInfo, SchedInfoStruct{
-			sector,
-			task,
-		})
+		key := SchedQueueInfo{Task: string(req.TaskType), Priority: req.Priority}
+		i, ok := queueIdx[key]
+		if !ok {
+			i = len(reSched.Queue)
+			queueIdx[key] = i
+			reSched.Queue = append(reSched.Queue, key)
+		}
+		reSched.Queue[i].Count++
+	}
+	for _, worker := range schedParseInfo.SchedInfo.OpenWindows {
+		reSched.OpenWindows[worker]++
 	}
 
-	return reSchedInfo
+	return reSched
+}
+
+// GetSealingConfig returns the sealing settings the miner exposes over its API:
+// the seal delay, the expected seal duration and the control address configuration.
+// The rest of the [Sealing] section is not readable over the API of this lotus version.
+func GetSealingConfig(ctx context.Context, mi lotusapi.StorageMinerStruct) (SealingConfigInfo, error) {
+	sealDelay, err := mi.SectorGetSealDelay(ctx)
+	if err != nil {
+		return SealingConfigInfo{}, err
+	}
+
+	expectedSealDuration, err := mi.SectorGetExpectedSealDuration(ctx)
+	if err != nil {
+		return SealingConfigInfo{}, err
+	}
+
+	addrConfig, err := mi.ActorAddressConfig(ctx)
+	if err != nil {
+		return SealingConfigInfo{}, err
+	}
+
+	controlAddrs := map[string][]string{}
+	for use, addrs := range map[string][]address.Address{
+		"precommit":    addrConfig.PreCommitControl,
+		"commit":       addrConfig.CommitControl,
+		"terminate":    addrConfig.TerminateControl,
+		"deal_publish": addrConfig.DealPublishControl,
+	} {
+		for _, addr := range addrs {
+			controlAddrs[use] = append(controlAddrs[use], addr.String())
+		}
+	}
+
+	return SealingConfigInfo{
+		SealDelay:             sealDelay,
+		ExpectedSealDuration:  expectedSealDuration,
+		ControlAddrs:          controlAddrs,
+		DisableOwnerFallback:  addrConfig.DisableOwnerFallback,
+		DisableWorkerFallback: addrConfig.DisableWorkerFallback,
+	}, nil
 }
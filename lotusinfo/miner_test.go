@@ -0,0 +1,33 @@
+package lotusinfo
+
+import "testing"
+
+func TestAddressRoles(t *testing.T) {
+	info := MinerInfoStruct{
+		Worker:     "f01001",
+		WorkerAddr: "f3worker",
+		Controls: []ControlAddrInfo{
+			{ID: "f01002", Addr: "f1post"},
+			{ID: "f01003", Addr: "f1commit"},
+		},
+	}
+
+	// owner is the worker: the address keeps its first role
+	roles := info.AddressRoles("f01001", "f3worker")
+	want := map[string]string{
+		"f01001":   "owner",
+		"f3worker": "owner",
+		"f01002":   "control0",
+		"f1post":   "control0",
+		"f01003":   "control1",
+		"f1commit": "control1",
+	}
+	if len(roles) != len(want) {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+	for addr, role := range want {
+		if roles[addr] != role {
+			t.Errorf("%s: role %q, want %q", addr, roles[addr], role)
+		}
+	}
+}
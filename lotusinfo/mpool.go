@@ -0,0 +1,87 @@
+package lotusinfo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// MpoolStuckInfo summarises how long the local messages of one sender have been pending.
+type MpoolStuckInfo struct {
+	Mfrom        string
+	OldestAge    float64
+	Stuck        int
+	BelowBasefee int
+}
+
+type mpoolSeen struct {
+	epoch int64
+	time  time.Time
+}
+
+// mpoolNonce identifies a pending message across replacements: a message
+// replaced with a higher fee keeps its sender and nonce but gets a new CID.
+type mpoolNonce struct {
+	from  string
+	nonce uint64
+}
+
+// MpoolTracker remembers when each pending local nonce was first seen,
+// so messages that sit in the mpool across scrapes can be detected.
+type MpoolTracker struct {
+	mutex     sync.Mutex
+	firstSeen map[mpoolNonce]mpoolSeen
+}
+
+func NewMpoolTracker() *MpoolTracker {
+	return &MpoolTracker{firstSeen: map[mpoolNonce]mpoolSeen{}}
+}
+
+// Update records the current pending local messages, forgets the ones that left
+// the mpool and returns per-sender age information. A message is stuck once it
+// has been pending for more than stuckEpochs epochs; replacing it keeps its age.
+func (t *MpoolTracker) Update(msgList []MpoolMsg, chainHeight int64, basefee types.BigInt, stuckEpochs int64) []MpoolStuckInfo {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	pending := make(map[mpoolNonce]struct{}, len(msgList))
+	addrIdx := map[string]int{}
+	var reStuck []MpoolStuckInfo
+	for _, msg := range msgList {
+		key := mpoolNonce{msg.Mfrom, msg.Mnonce}
+		pending[key] = struct{}{}
+		seen, ok := t.firstSeen[key]
+		if !ok {
+			seen = mpoolSeen{chainHeight, now}
+			t.firstSeen[key] = seen
+		}
+
+		i, ok := addrIdx[msg.Mfrom]
+		if !ok {
+			i = len(reStuck)
+			addrIdx[msg.Mfrom] = i
+			reStuck = append(reStuck, MpoolStuckInfo{Mfrom: msg.Mfrom})
+		}
+
+		age := now.Sub(seen.time).Seconds()
+		if age > reStuck[i].OldestAge {
+			reStuck[i].OldestAge = age
+		}
+		if chainHeight-seen.epoch > stuckEpochs {
+			reStuck[i].Stuck++
+			if msg.Mgasfeecap.LessThan(basefee) {
+				reStuck[i].BelowBasefee++
+			}
+		}
+	}
+
+	for key := range t.firstSeen {
+		if _, ok := pending[key]; !ok {
+			delete(t.firstSeen, key)
+		}
+	}
+
+	return reStuck
+}
@@ -0,0 +1,36 @@
+package lotusinfo
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestMpoolTrackerReplacementKeepsAge(t *testing.T) {
+	tracker := NewMpoolTracker()
+	basefee := types.NewInt(100)
+	msg := MpoolMsg{Mcid: "bafy-original", Mfrom: "f01001", Mnonce: 5, Mgasfeecap: types.NewInt(50)}
+
+	tracker.Update([]MpoolMsg{msg}, 1000, basefee, 10)
+
+	// replaced with a higher fee cap: same sender and nonce, new CID
+	msg.Mcid = "bafy-replacement"
+	msg.Mgasfeecap = types.NewInt(200)
+	stuck := tracker.Update([]MpoolMsg{msg}, 1020, basefee, 10)
+	if len(stuck) != 1 {
+		t.Fatalf("expected one sender, got %+v", stuck)
+	}
+	if stuck[0].Stuck != 1 {
+		t.Errorf("replaced message lost its first seen epoch: %+v", stuck[0])
+	}
+	if stuck[0].BelowBasefee != 0 {
+		t.Errorf("replacement fee cap above basefee counted as below: %+v", stuck[0])
+	}
+
+	// once the nonce lands it is forgotten
+	tracker.Update(nil, 1021, basefee, 10)
+	stuck = tracker.Update([]MpoolMsg{{Mcid: "bafy-next", Mfrom: "f01001", Mnonce: 5}}, 1030, basefee, 10)
+	if stuck[0].Stuck != 0 {
+		t.Errorf("new message with a landed nonce counted as stuck: %+v", stuck[0])
+	}
+}
@@ -0,0 +1,110 @@
+package lotusinfo
+
+import (
+	"context"
+	"sort"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+// NetReachabilities are the NAT reachability states reported by NetAutoNatStatus.
+var NetReachabilities = []string{
+	network.ReachabilityUnknown.String(),
+	network.ReachabilityPublic.String(),
+	network.ReachabilityPrivate.String(),
+}
+
+type NetProtocolStats struct {
+	Protocol string
+	TotalIn  int64
+	TotalOut int64
+}
+
+type NetInfo struct {
+	Peers        int
+	TotalIn      int64
+	TotalOut     int64
+	Protocols    []NetProtocolStats
+	Reachability string
+}
+
+// PubsubScoreStats is the distribution of the gossipsub scores a node gives its peers.
+type PubsubScoreStats struct {
+	Peers  int
+	Sum    float64
+	Min    float64
+	Median float64
+	Max    float64
+}
+
+// GetNetInfo returns the libp2p view of one lotus endpoint.
+func GetNetInfo(ctx context.Context, na lotusapi.Net) (NetInfo, error) {
+	var netInfo NetInfo
+
+	peers, err := na.NetPeers(ctx)
+	if err != nil {
+		return netInfo, err
+	}
+	netInfo.Peers = len(peers)
+
+	bw, err := na.NetBandwidthStats(ctx)
+	if err != nil {
+		return netInfo, err
+	}
+	netInfo.TotalIn = bw.TotalIn
+	netInfo.TotalOut = bw.TotalOut
+
+	bwByProto, err := na.NetBandwidthStatsByProtocol(ctx)
+	if err != nil {
+		return netInfo, err
+	}
+	for proto, stats := range bwByProto {
+		protoName := string(proto)
+		if protoName == "" {
+			protoName = "unknown"
+		}
+		netInfo.Protocols = append(netInfo.Protocols, NetProtocolStats{protoName, stats.TotalIn, stats.TotalOut})
+	}
+
+	nat, err := na.NetAutoNatStatus(ctx)
+	if err != nil {
+		return netInfo, err
+	}
+	netInfo.Reachability = nat.Reachability.String()
+
+	return netInfo, nil
+}
+
+// GetPubsubScores returns the distribution of the scores the daemon gives its
+// gossipsub peers. lotus-miner does not join gossipsub, so only the daemon has scores.
+func GetPubsubScores(ctx context.Context, na lotusapi.Net) (PubsubScoreStats, error) {
+	var stats PubsubScoreStats
+
+	scores, err := na.NetPubsubScores(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	var values []float64
+	for _, score := range scores {
+		if score.Score != nil {
+			values = append(values, score.Score.Score)
+			stats.Sum += score.Score.Score
+		}
+	}
+	if len(values) == 0 {
+		return stats, nil
+	}
+
+	sort.Float64s(values)
+	stats.Peers = len(values)
+	stats.Min = values[0]
+	stats.Max = values[len(values)-1]
+	if mid := len(values) / 2; len(values)%2 == 1 {
+		stats.Median = values[mid]
+	} else {
+		stats.Median = (values[mid-1] + values[mid]) / 2
+	}
+	return stats, nil
+}
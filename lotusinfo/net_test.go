@@ -0,0 +1,31 @@
+package lotusinfo
+
+import (
+	"context"
+	"testing"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+func TestGetPubsubScores(t *testing.T) {
+	var fu lotusapi.FullNodeStruct
+	fu.NetStruct.Internal.NetPubsubScores = func(ctx context.Context) ([]lotusapi.PubsubScore, error) {
+		return []lotusapi.PubsubScore{
+			{ID: "peer-a", Score: &pubsub.PeerScoreSnapshot{Score: 4}},
+			{ID: "peer-b", Score: &pubsub.PeerScoreSnapshot{Score: -10}},
+			{ID: "peer-c"},
+			{ID: "peer-d", Score: &pubsub.PeerScoreSnapshot{Score: 2}},
+			{ID: "peer-e", Score: &pubsub.PeerScoreSnapshot{Score: 8}},
+		}, nil
+	}
+
+	stats, err := GetPubsubScores(context.Background(), &fu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PubsubScoreStats{Peers: 4, Sum: 4, Min: -10, Median: 3, Max: 8}
+	if stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+}
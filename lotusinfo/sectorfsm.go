@@ -0,0 +1,157 @@
+package lotusinfo
+
+import (
+	"encoding/json"
+	"strings"
+
+	lotusapi "github.com/filecoin-project/lotus/api"
+)
+
+// sectorEventStates is the state a sector moves to on each sealing event, as
+// planned by the lotus v1.15 storage-sealing state machine (fsmPlanners). Events
+// whose next state depends on the current one are resolved in sectorEventState;
+// events not listed, like SectorRestart or SectorFatalError, keep the state.
+var sectorEventStates = map[string]string{
+	"SectorStart":                        "WaitDeals",
+	"SectorStartCC":                      "Packing",
+	"SectorStartCCUpdate":                "SnapDealsWaitDeals",
+	"SectorTicket":                       "PreCommit1",
+	"SectorOldTicket":                    "GetTicket",
+	"SectorPreCommit1":                   "PreCommit2",
+	"SectorPreCommit2":                   "PreCommitting",
+	"SectorPreCommitBatch":               "SubmitPreCommitBatch",
+	"SectorPreCommitBatchSent":           "PreCommitBatchWait",
+	"SectorPreCommitted":                 "PreCommitWait",
+	"SectorPreCommitLanded":              "WaitSeed",
+	"SectorSeedReady":                    "Committing",
+	"SectorCommitted":                    "SubmitCommit",
+	"SectorProofReady":                   "CommitFinalize",
+	"SectorSubmitCommitAggregate":        "SubmitCommitAggregate",
+	"SectorCommitAggregateSent":          "CommitAggregateWait",
+	"SectorCommitSubmitted":              "CommitWait",
+	"SectorProving":                      "FinalizeSector",
+	"SectorReplicaUpdate":                "ProveReplicaUpdate",
+	"SectorProveReplicaUpdate":           "SubmitReplicaUpdate",
+	"SectorUpdateDealIDs":                "SubmitReplicaUpdate",
+	"SectorReplicaUpdateSubmitted":       "ReplicaUpdateWait",
+	"SectorReplicaUpdateLanded":          "FinalizeReplicaUpdate",
+	"SectorUpdateActive":                 "ReleaseSectorKey",
+	"SectorKeyReleased":                  "Proving",
+	"SectorRevertUpgradeToProving":       "Proving",
+	"SectorAbortUpgrade":                 "AbortUpgrade",
+	"SectorSealPreCommit1Failed":         "SealPreCommit1Failed",
+	"SectorSealPreCommit2Failed":         "SealPreCommit2Failed",
+	"SectorChainPreCommitFailed":         "PreCommitFailed",
+	"SectorComputeProofFailed":           "ComputeProofFailed",
+	"SectorCommitFailed":                 "CommitFailed",
+	"SectorUpdateReplicaFailed":          "ReplicaUpdateFailed",
+	"SectorProveReplicaUpdateFailed":     "ReplicaUpdateFailed",
+	"SectorSubmitReplicaUpdateFailed":    "ReplicaUpdateFailed",
+	"SectorReleaseKeyFailed":             "ReleaseSectorKeyFailed",
+	"SectorRetrySealPreCommit1":          "PreCommit1",
+	"SectorRetrySealPreCommit2":          "PreCommit2",
+	"SectorRetryPreCommit":               "PreCommitting",
+	"SectorRetryPreCommitWait":           "PreCommitWait",
+	"SectorRetryWaitSeed":                "WaitSeed",
+	"SectorRetryComputeProof":            "Committing",
+	"SectorRetryInvalidProof":            "Committing",
+	"SectorRetrySubmitCommit":            "SubmitCommit",
+	"SectorRetryCommitWait":              "CommitWait",
+	"SectorRetryReplicaUpdate":           "UpdateReplica",
+	"SectorRetryProveReplicaUpdate":      "ProveReplicaUpdate",
+	"SectorRetrySubmitReplicaUpdate":     "SubmitReplicaUpdate",
+	"SectorRetrySubmitReplicaUpdateWait": "ReplicaUpdateWait",
+	"SectorFaulty":                       "Faulty",
+	"SectorFaultReported":                "FaultReported",
+	"SectorTerminate":                    "Terminating",
+	"SectorTerminating":                  "TerminateWait",
+	"SectorTerminated":                   "TerminateFinality",
+	"SectorTerminateFailed":              "TerminateFailed",
+	"SectorTicketExpired":                "Removing",
+	"SectorRemove":                       "Removing",
+	"SectorRemoved":                      "Removed",
+	"SectorRemoveFailed":                 "RemoveFailed",
+}
+
+// snapDealsStates are the states of a snap deals upgrade, in which the deal
+// events move a sector to the SnapDeals variant of their state.
+var snapDealsStates = map[string]bool{
+	"SnapDealsWaitDeals":          true,
+	"SnapDealsAddPiece":           true,
+	"SnapDealsPacking":            true,
+	"SnapDealsAddPieceFailed":     true,
+	"SnapDealsDealsExpired":       true,
+	"SnapDealsRecoverDealIDs":     true,
+	"UpdateReplica":               true,
+	"ProveReplicaUpdate":          true,
+	"SubmitReplicaUpdate":         true,
+	"ReplicaUpdateWait":           true,
+	"FinalizeReplicaUpdate":       true,
+	"ReplicaUpdateFailed":         true,
+	"FinalizeReplicaUpdateFailed": true,
+}
+
+// sectorEventState returns the state a sector in state moves to on the event
+// logged in entry, or "" when the entry is not an event or keeps the state.
+func sectorEventState(state string, entry lotusapi.SectorLog) string {
+	if !strings.HasPrefix(entry.Kind, "event;sealing.") {
+		return ""
+	}
+	event := strings.TrimPrefix(entry.Kind, "event;sealing.")
+
+	snap := snapDealsStates[state]
+	pick := func(sealing, snapDeals string) string {
+		if snap {
+			return snapDeals
+		}
+		return sealing
+	}
+
+	switch event {
+	case "SectorForceState":
+		var forced struct{ State string }
+		if err := json.Unmarshal([]byte(entry.Message), &forced); err != nil {
+			return ""
+		}
+		return forced.State
+	case "SectorAddPiece":
+		return pick("AddPiece", "SnapDealsAddPiece")
+	case "SectorPieceAdded", "SectorRetryWaitDeals":
+		return pick("WaitDeals", "SnapDealsWaitDeals")
+	case "SectorStartPacking":
+		return pick("Packing", "SnapDealsPacking")
+	case "SectorAddPieceFailed":
+		return pick("AddPieceFailed", "SnapDealsAddPieceFailed")
+	case "SectorDealsExpired":
+		return pick("DealsExpired", "SnapDealsDealsExpired")
+	case "SectorInvalidDealIDs":
+		return pick("RecoverDealIDs", "SnapDealsRecoverDealIDs")
+	case "SectorPacked":
+		return pick("GetTicket", "UpdateReplica")
+	case "SectorFinalized":
+		switch state {
+		case "CommitFinalize":
+			return "SubmitCommit"
+		case "FinalizeReplicaUpdate":
+			return "UpdateActivating"
+		}
+		return "Proving"
+	case "SectorFinalizeFailed":
+		switch state {
+		case "CommitFinalize":
+			return "CommitFinalizeFailed"
+		case "FinalizeReplicaUpdate":
+			return "FinalizeReplicaUpdateFailed"
+		}
+		return "FinalizeFailed"
+	case "SectorRetryFinalize":
+		switch state {
+		case "CommitFinalizeFailed":
+			return "CommitFinalize"
+		case "FinalizeReplicaUpdateFailed":
+			return "FinalizeReplicaUpdate"
+		}
+		return "FinalizeSector"
+	}
+	return sectorEventStates[event]
+}
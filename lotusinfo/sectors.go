@@ -0,0 +1,319 @@
+package lotusinfo
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+)
+
+// settledSectorStates are the states a sector stays in once sealing is over,
+// terminal states and failures that need an operator; sectors in them are not
+// polled again.
+var settledSectorStates = map[string]bool{
+	"Proving":             true,
+	"Available":           true,
+	"FaultedFinal":        true,
+	"TerminateFinality":   true,
+	"TerminateFailed":     true,
+	"Terminated":          true,
+	"Removed":             true,
+	"RemoveFailed":        true,
+	"FailedUnrecoverable": true,
+	"DealsExpired":        true,
+}
+
+// sectorStatusWorkers bounds the SectorsStatus calls running at the same time.
+const sectorStatusWorkers = 8
+
+type SectorStateStats struct {
+	State   string
+	Entered uint64
+	Seconds float64
+	Left    uint64
+}
+
+type SectorStageStats struct {
+	Stage     string
+	Durations Histogram
+}
+
+type SectorStats struct {
+	States []SectorStateStats
+	Stages []SectorStageStats
+}
+
+// sectorStages are the sealing stages timed from the sector event log. A stage
+// runs from the last of its start events to the last of its end events, the
+// events being logged with the kind "event;sealing.<Event>". With FinalizeEarly
+// Committing ends with SectorProofReady and no CommitWait is timed.
+var sectorStages = []struct {
+	stage string
+	start []string
+	end   []string
+}{
+	{"AddPiece", []string{"SectorAddPiece"}, []string{"SectorPieceAdded"}},
+	{"Packing", []string{"SectorStartCC", "SectorStartPacking"}, []string{"SectorPacked"}},
+	{"PreCommit1", []string{"SectorTicket"}, []string{"SectorPreCommit1"}},
+	{"PreCommit2", []string{"SectorPreCommit1"}, []string{"SectorPreCommit2"}},
+	{"PreCommitting", []string{"SectorPreCommit2"}, []string{"SectorPreCommitted", "SectorPreCommitBatchSent"}},
+	{"PreCommitWait", []string{"SectorPreCommitted", "SectorPreCommitBatchSent"}, []string{"SectorPreCommitLanded"}},
+	{"WaitSeed", []string{"SectorPreCommitLanded"}, []string{"SectorSeedReady"}},
+	{"Committing", []string{"SectorSeedReady"}, []string{"SectorCommitted", "SectorProofReady"}},
+	{"CommitWait", []string{"SectorCommitted"}, []string{"SectorProving"}},
+	{"FinalizeSector", []string{"SectorProving"}, []string{"SectorFinalized"}},
+}
+
+var sectorStageBuckets = []float64{60, 300, 900, 1800, 3600, 2 * 3600, 4 * 3600, 6 * 3600, 8 * 3600, 12 * 3600, 24 * 3600, 48 * 3600}
+
+// sectorStageDurations returns the duration in seconds of each stage found in
+// the event log of a sector.
+func sectorStageDurations(sectorLog []lotusapi.SectorLog) map[string]float64 {
+	last := map[string]uint64{}
+	for _, entry := range sectorLog {
+		if !strings.HasPrefix(entry.Kind, "event;sealing.") {
+			continue
+		}
+		last[strings.TrimPrefix(entry.Kind, "event;sealing.")] = entry.Timestamp
+	}
+
+	latest := func(events []string) (ts uint64, ok bool) {
+		for _, event := range events {
+			if t, found := last[event]; found && t >= ts {
+				ts, ok = t, true
+			}
+		}
+		return ts, ok
+	}
+
+	durations := map[string]float64{}
+	for _, stage := range sectorStages {
+		start, ok := latest(stage.start)
+		if !ok {
+			continue
+		}
+		end, ok := latest(stage.end)
+		if !ok || end < start {
+			continue
+		}
+		durations[stage.stage] = float64(end - start)
+	}
+	return durations
+}
+
+type sectorSeen struct {
+	state   string
+	entered time.Time
+	// log entries walked so far, and the timestamp of the last one
+	logLen  int
+	logLast uint64
+}
+
+// SectorTracker remembers the state of each sector between refreshes and counts
+// the state changes it observes. The states a sector passed through between two
+// refreshes are replayed from the events of its log, with their timestamps.
+type SectorTracker struct {
+	mutex   sync.Mutex
+	sectors map[abi.SectorNumber]sectorSeen
+	stats   map[string]*SectorStateStats
+	stages  map[string]*Histogram
+	primed  bool
+}
+
+func NewSectorTracker() *SectorTracker {
+	return &SectorTracker{
+		sectors: map[abi.SectorNumber]sectorSeen{},
+		stats:   map[string]*SectorStateStats{},
+		stages:  map[string]*Histogram{},
+	}
+}
+
+type sectorStatus struct {
+	num  abi.SectorNumber
+	info lotusapi.SectorInfo
+}
+
+// Update polls the state of every sector that is not settled yet, at most
+// sectorStatusWorkers at a time, and records the sectors entering and leaving
+// each state, with the time spent in the states they left, and the stage
+// durations of the sectors that reached Proving. The first refresh only records
+// the states. Update is meant to run in the background, one call at a time;
+// Stats does not wait for the polling.
+func (t *SectorTracker) Update(ctx context.Context, mi lotusapi.StorageMinerStruct) error {
+	sectorList, err := mi.SectorsList(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	var toPoll []abi.SectorNumber
+	for _, sectorNum := range sectorList {
+		if seen, ok := t.sectors[sectorNum]; !ok || !settledSectorStates[seen.state] {
+			toPoll = append(toPoll, sectorNum)
+		}
+	}
+	t.mutex.Unlock()
+
+	statuses := pollSectorsStatus(ctx, mi, toPoll)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, status := range statuses {
+		t.sectorStatus(status.num, status.info)
+	}
+	t.primed = true
+
+	listed := make(map[abi.SectorNumber]struct{}, len(sectorList))
+	for _, sectorNum := range sectorList {
+		listed[sectorNum] = struct{}{}
+	}
+	for sectorNum := range t.sectors {
+		if _, ok := listed[sectorNum]; !ok {
+			delete(t.sectors, sectorNum)
+		}
+	}
+
+	return nil
+}
+
+// pollSectorsStatus gets the status of the sectors with sectorStatusWorkers
+// concurrent calls, leaving out the sectors whose status failed.
+func pollSectorsStatus(ctx context.Context, mi lotusapi.StorageMinerStruct, sectorList []abi.SectorNumber) []sectorStatus {
+	results := make([]*sectorStatus, len(sectorList))
+	parallel(len(sectorList), sectorStatusWorkers, func(i int) {
+		sectorInfo, err := mi.SectorsStatus(ctx, sectorList[i], false)
+		if err != nil {
+			log.Printf("get sector %d status: %s", sectorList[i], err)
+			return
+		}
+		results[i] = &sectorStatus{sectorList[i], sectorInfo}
+	})
+
+	var statuses []sectorStatus
+	for _, status := range results {
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+	}
+	return statuses
+}
+
+// parallel calls fn for every index below count, at most workers at a time,
+// and returns once all calls are done.
+func parallel(count int, workers int, fn func(i int)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// sectorStatus records the polled state of a sector and the transitions of
+// the events logged since the last refresh. The caller holds the mutex.
+func (t *SectorTracker) sectorStatus(sectorNum abi.SectorNumber, sectorInfo lotusapi.SectorInfo) {
+	seen := t.sectors[sectorNum]
+	state := string(sectorInfo.State)
+	sectorLog := sectorInfo.Log
+
+	var logLast uint64
+	entered := time.Now()
+	if n := len(sectorLog); n > 0 {
+		logLast = sectorLog[n-1].Timestamp
+		entered = time.Unix(int64(logLast), 0)
+	}
+	if !t.primed {
+		t.sectors[sectorNum] = sectorSeen{state, entered, len(sectorLog), logLast}
+		return
+	}
+	// a sector created since the first refresh is replayed from its first event
+
+	// lotus truncates long logs; then resume after the last walked timestamp
+	from := seen.logLen
+	if from > len(sectorLog) || (from > 0 && sectorLog[from-1].Timestamp != seen.logLast) {
+		from = 0
+		for from < len(sectorLog) && sectorLog[from].Timestamp <= seen.logLast {
+			from++
+		}
+	}
+
+	current := seen
+	reachedProving := false
+	transition := func(next string, at time.Time) {
+		t.stateStats(next).Entered++
+		if current.state != "" {
+			left := t.stateStats(current.state)
+			left.Left++
+			left.Seconds += at.Sub(current.entered).Seconds()
+		}
+		current.state, current.entered = next, at
+		if next == "Proving" {
+			reachedProving = true
+		}
+	}
+	for _, entry := range sectorLog[from:] {
+		next := sectorEventState(current.state, entry)
+		if next == "" || next == current.state {
+			continue
+		}
+		transition(next, time.Unix(int64(entry.Timestamp), 0))
+	}
+	// an event missing from the table must not leave the sector in a stale state
+	if current.state != state {
+		transition(state, entered)
+	}
+	current.logLen, current.logLast = len(sectorLog), logLast
+	t.sectors[sectorNum] = current
+
+	if reachedProving {
+		for stage, seconds := range sectorStageDurations(sectorLog) {
+			hist, ok := t.stages[stage]
+			if !ok {
+				h := NewHistogram(sectorStageBuckets)
+				hist = &h
+				t.stages[stage] = hist
+			}
+			hist.Observe(seconds)
+		}
+	}
+}
+
+// Stats returns the per-state counters and stage durations recorded by the
+// refreshes so far.
+func (t *SectorTracker) Stats() SectorStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var reStats SectorStats
+	for _, stats := range t.stats {
+		reStats.States = append(reStats.States, *stats)
+	}
+	sort.Slice(reStats.States, func(i, j int) bool { return reStats.States[i].State < reStats.States[j].State })
+	for _, stage := range sectorStages {
+		if hist, ok := t.stages[stage.stage]; ok {
+			reStats.Stages = append(reStats.Stages, SectorStageStats{stage.stage, hist.Copy()})
+		}
+	}
+	return reStats
+}
+
+func (t *SectorTracker) stateStats(state string) *SectorStateStats {
+	stats, ok := t.stats[state]
+	if !ok {
+		stats = &SectorStateStats{State: state}
+		t.stats[state] = stats
+	}
+	return stats
+}
@@ -0,0 +1,311 @@
+package lotusinfo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	lotusapi "github.com/filecoin-project/lotus/api"
+)
+
+func TestSectorTrackerPolling(t *testing.T) {
+	var mutex sync.Mutex
+	states := map[abi.SectorNumber]string{}
+	polled := map[abi.SectorNumber]int{}
+	running, maxRunning := 0, 0
+	for n := abi.SectorNumber(0); n < 100; n++ {
+		states[n] = "Proving"
+	}
+	states[100] = "PreCommit1"
+	states[101] = "FailedUnrecoverable"
+	states[102] = "TerminateFinality"
+
+	var mi lotusapi.StorageMinerStruct
+	mi.Internal.SectorsList = func(ctx context.Context) ([]abi.SectorNumber, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var sectorList []abi.SectorNumber
+		for n := range states {
+			sectorList = append(sectorList, n)
+		}
+		return sectorList, nil
+	}
+	mi.Internal.SectorsStatus = func(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (lotusapi.SectorInfo, error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		polled[sid]++
+		state := states[sid]
+		mutex.Unlock()
+
+		defer func() {
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+		}()
+		return lotusapi.SectorInfo{SectorID: sid, State: lotusapi.SectorState(state)}, nil
+	}
+
+	tracker := NewSectorTracker()
+	ctx := context.Background()
+	if err := tracker.Update(ctx, mi); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning > sectorStatusWorkers {
+		t.Errorf("%d concurrent SectorsStatus calls, limit %d", maxRunning, sectorStatusWorkers)
+	}
+	if stats := tracker.Stats(); len(stats.States) != 0 {
+		t.Errorf("first refresh should only record states, got %+v", stats.States)
+	}
+
+	mutex.Lock()
+	states[100] = "PreCommit2"
+	mutex.Unlock()
+	if err := tracker.Update(ctx, mi); err != nil {
+		t.Fatal(err)
+	}
+
+	for n, count := range polled {
+		if n == 100 && count != 2 {
+			t.Errorf("unsettled sector polled %d times", count)
+		}
+		if n != 100 && count != 1 {
+			t.Errorf("settled sector %d polled %d times", n, count)
+		}
+	}
+
+	entered := map[string]uint64{}
+	for _, state := range tracker.Stats().States {
+		entered[state.State] = state.Entered
+	}
+	if len(entered) != 2 || entered["PreCommit2"] != 1 || entered["PreCommit1"] != 0 {
+		t.Errorf("unexpected state counters %v", entered)
+	}
+}
+
+// sealingEvent is a log entry of a lotus sealing event at timestamp.
+func sealingEvent(event string, timestamp uint64) lotusapi.SectorLog {
+	return lotusapi.SectorLog{Kind: "event;sealing." + event, Timestamp: timestamp, Message: "{}"}
+}
+
+func TestSectorTrackerReplaysLoggedTransitions(t *testing.T) {
+	sectorLog := []lotusapi.SectorLog{
+		sealingEvent("SectorStartCC", 100),
+		sealingEvent("SectorPacked", 110),
+		sealingEvent("SectorTicket", 120),
+	}
+	status := lotusapi.SectorInfo{SectorID: 1, State: "PreCommit1"}
+
+	var mi lotusapi.StorageMinerStruct
+	mi.Internal.SectorsList = func(ctx context.Context) ([]abi.SectorNumber, error) {
+		return []abi.SectorNumber{1}, nil
+	}
+	mi.Internal.SectorsStatus = func(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (lotusapi.SectorInfo, error) {
+		status.Log = sectorLog
+		return status, nil
+	}
+
+	tracker := NewSectorTracker()
+	ctx := context.Background()
+	if err := tracker.Update(ctx, mi); err != nil {
+		t.Fatal(err)
+	}
+
+	// between two refreshes PC1 fails once and is retried, then the sector
+	// moves on to PreCommitWait
+	sectorLog = append(sectorLog,
+		sealingEvent("SectorSealPreCommit1Failed", 200),
+		lotusapi.SectorLog{Kind: "error;xerrors.wrapError", Timestamp: 201},
+		sealingEvent("SectorRetrySealPreCommit1", 260),
+		sealingEvent("SectorPreCommit1", 400),
+		sealingEvent("SectorPreCommit2", 500),
+		sealingEvent("SectorPreCommitted", 510),
+	)
+	status.State = "PreCommitWait"
+	if err := tracker.Update(ctx, mi); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]SectorStateStats{}
+	for _, state := range tracker.Stats().States {
+		got[state.State] = state
+	}
+	want := map[string]SectorStateStats{
+		"PreCommit1":           {State: "PreCommit1", Entered: 1, Left: 2, Seconds: (200 - 120) + (400 - 260)},
+		"SealPreCommit1Failed": {State: "SealPreCommit1Failed", Entered: 1, Left: 1, Seconds: 60},
+		"PreCommit2":           {State: "PreCommit2", Entered: 1, Left: 1, Seconds: 100},
+		"PreCommitting":        {State: "PreCommitting", Entered: 1, Left: 1, Seconds: 10},
+		"PreCommitWait":        {State: "PreCommitWait", Entered: 1},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got states %+v, want %+v", got, want)
+	}
+	for state, stats := range want {
+		if got[state] != stats {
+			t.Errorf("%s: got %+v, want %+v", state, got[state], stats)
+		}
+	}
+}
+
+func TestSectorEventState(t *testing.T) {
+	for _, tc := range []struct {
+		state string
+		entry lotusapi.SectorLog
+		want  string
+	}{
+		{"Committing", sealingEvent("SectorCommitted", 1), "SubmitCommit"},
+		{"Committing", sealingEvent("SectorProofReady", 1), "CommitFinalize"},
+		{"CommitFinalize", sealingEvent("SectorFinalized", 1), "SubmitCommit"},
+		{"FinalizeSector", sealingEvent("SectorFinalized", 1), "Proving"},
+		{"FinalizeReplicaUpdate", sealingEvent("SectorFinalized", 1), "UpdateActivating"},
+		{"FinalizeFailed", sealingEvent("SectorRetryFinalize", 1), "FinalizeSector"},
+		{"WaitDeals", sealingEvent("SectorAddPiece", 1), "AddPiece"},
+		{"SnapDealsWaitDeals", sealingEvent("SectorAddPiece", 1), "SnapDealsAddPiece"},
+		{"SnapDealsPacking", sealingEvent("SectorPacked", 1), "UpdateReplica"},
+		{"Packing", sealingEvent("SectorPacked", 1), "GetTicket"},
+		{"Proving", lotusapi.SectorLog{Kind: "event;sealing.SectorForceState", Message: `{"State":"Removing"}`}, "Removing"},
+		{"PreCommit1", sealingEvent("SectorRestart", 1), ""},
+		{"PreCommit1", lotusapi.SectorLog{Kind: "error;xerrors.wrapError"}, ""},
+	} {
+		if got := sectorEventState(tc.state, tc.entry); got != tc.want {
+			t.Errorf("%s on %s: got %q, want %q", tc.state, tc.entry.Kind, got, tc.want)
+		}
+	}
+}
+
+func TestSectorStageDurations(t *testing.T) {
+	// event logs as written by lotus v1.15 storage-sealing, with the restarts
+	// and errors interleaved in real logs
+	ccPrefix := []lotusapi.SectorLog{
+		sealingEvent("SectorStartCC", 1000),
+		sealingEvent("SectorPacked", 1030),
+		sealingEvent("SectorTicket", 1040),
+	}
+
+	for _, tc := range []struct {
+		name string
+		log  []lotusapi.SectorLog
+		want map[string]float64
+	}{
+		{
+			name: "non-batched",
+			log: append(ccPrefix[:3:3],
+				sealingEvent("SectorPreCommit1", 4640),
+				sealingEvent("SectorPreCommit2", 5240),
+				sealingEvent("SectorPreCommitted", 5250),
+				sealingEvent("SectorPreCommitLanded", 5850),
+				sealingEvent("SectorSeedReady", 10350),
+				sealingEvent("SectorCommitted", 12150),
+				sealingEvent("SectorCommitSubmitted", 12160),
+				sealingEvent("SectorProving", 12760),
+				sealingEvent("SectorFinalized", 12880),
+			),
+			want: map[string]float64{
+				"Packing": 30, "PreCommit1": 3600, "PreCommit2": 600, "PreCommitting": 10, "PreCommitWait": 600,
+				"WaitSeed": 4500, "Committing": 1800, "CommitWait": 610, "FinalizeSector": 120,
+			},
+		},
+		{
+			name: "deals and precommit batch",
+			log: []lotusapi.SectorLog{
+				sealingEvent("SectorStart", 900),
+				sealingEvent("SectorAddPiece", 950),
+				sealingEvent("SectorPieceAdded", 990),
+				sealingEvent("SectorStartPacking", 1000),
+				sealingEvent("SectorPacked", 1020),
+				sealingEvent("SectorTicket", 1040),
+				sealingEvent("SectorPreCommit1", 4640),
+				sealingEvent("SectorPreCommit2", 5240),
+				sealingEvent("SectorPreCommitBatch", 5241),
+				sealingEvent("SectorPreCommitBatchSent", 7000),
+				sealingEvent("SectorPreCommitLanded", 7600),
+				sealingEvent("SectorSeedReady", 12100),
+				sealingEvent("SectorCommitted", 13900),
+				sealingEvent("SectorCommitSubmitted", 13910),
+				sealingEvent("SectorProving", 14510),
+				sealingEvent("SectorFinalized", 14630),
+			},
+			want: map[string]float64{
+				"AddPiece": 40, "Packing": 20, "PreCommit1": 3600, "PreCommit2": 600, "PreCommitting": 1760, "PreCommitWait": 600,
+				"WaitSeed": 4500, "Committing": 1800, "CommitWait": 610, "FinalizeSector": 120,
+			},
+		},
+		{
+			name: "commit aggregate",
+			log: append(ccPrefix[:3:3],
+				sealingEvent("SectorPreCommit1", 4640),
+				sealingEvent("SectorPreCommit2", 5240),
+				sealingEvent("SectorPreCommitted", 5250),
+				sealingEvent("SectorPreCommitLanded", 5850),
+				sealingEvent("SectorSeedReady", 10350),
+				sealingEvent("SectorCommitted", 12150),
+				sealingEvent("SectorSubmitCommitAggregate", 12151),
+				sealingEvent("SectorCommitAggregateSent", 15000),
+				sealingEvent("SectorProving", 15600),
+				sealingEvent("SectorFinalized", 15720),
+			),
+			want: map[string]float64{
+				"Packing": 30, "PreCommit1": 3600, "PreCommit2": 600, "PreCommitting": 10, "PreCommitWait": 600,
+				"WaitSeed": 4500, "Committing": 1800, "CommitWait": 3450, "FinalizeSector": 120,
+			},
+		},
+		{
+			name: "retried PC1",
+			log: append(ccPrefix[:3:3],
+				sealingEvent("SectorSealPreCommit1Failed", 2000),
+				lotusapi.SectorLog{Kind: "error;xerrors.wrapError", Timestamp: 2000},
+				sealingEvent("SectorRetrySealPreCommit1", 2060),
+				sealingEvent("SectorRestart", 2100),
+				sealingEvent("SectorPreCommit1", 5700),
+				sealingEvent("SectorPreCommit2", 6300),
+			),
+			want: map[string]float64{"Packing": 30, "PreCommit1": 4660, "PreCommit2": 600},
+		},
+		{
+			name: "PC1 redone after PC2",
+			log: append(ccPrefix[:3:3],
+				sealingEvent("SectorPreCommit1", 4640),
+				sealingEvent("SectorPreCommit2", 5240),
+				sealingEvent("SectorSealPreCommit1Failed", 5300),
+				sealingEvent("SectorRetrySealPreCommit1", 5360),
+				sealingEvent("SectorPreCommit1", 8960),
+			),
+			// PreCommit2 started again at 8960 and has not ended: end < start
+			want: map[string]float64{"Packing": 30, "PreCommit1": 7920},
+		},
+		{
+			name: "finalize early",
+			log: append(ccPrefix[:3:3],
+				sealingEvent("SectorPreCommit1", 4640),
+				sealingEvent("SectorPreCommit2", 5240),
+				sealingEvent("SectorPreCommitted", 5250),
+				sealingEvent("SectorPreCommitLanded", 5850),
+				sealingEvent("SectorSeedReady", 10350),
+				sealingEvent("SectorProofReady", 12150),
+				sealingEvent("SectorFinalized", 12270),
+				sealingEvent("SectorCommitSubmitted", 12280),
+				sealingEvent("SectorProving", 12880),
+				sealingEvent("SectorFinalized", 12890),
+			),
+			want: map[string]float64{
+				"Packing": 30, "PreCommit1": 3600, "PreCommit2": 600, "PreCommitting": 10, "PreCommitWait": 600,
+				"WaitSeed": 4500, "Committing": 1800, "FinalizeSector": 10,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sectorStageDurations(tc.log)
+			if len(got) != len(tc.want) {
+				t.Errorf("got stages %v, want %v", got, tc.want)
+			}
+			for stage, seconds := range tc.want {
+				if got[stage] != seconds {
+					t.Errorf("%s: got %v, want %v", stage, got[stage], seconds)
+				}
+			}
+		})
+	}
+}
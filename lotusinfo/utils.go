@@ -2,7 +2,10 @@ package lotusinfo
 
 import (
 	"encoding/json"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/multiformats/go-multiaddr"
+	"math/big"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -114,3 +117,23 @@ func Strval(value interface{}) string {
 
 	return key
 }
+
+// BigToFloat converts a big integer (bytes, attoFIL) to float64 without
+// passing through int64, so values above 2^63 keep their magnitude.
+func BigToFloat(value types.BigInt) float64 {
+	if value.Int == nil {
+		return 0
+	}
+	fl, _ := new(big.Float).SetInt(value.Int).Float64()
+	return fl
+}
+
+// AttoFilToFil converts an attoFIL amount to FIL.
+func AttoFilToFil(value types.BigInt) float64 {
+	if value.Int == nil {
+		return 0
+	}
+	fil := new(big.Float).Quo(new(big.Float).SetInt(value.Int), new(big.Float).SetUint64(build.FilecoinPrecision))
+	fl, _ := fil.Float64()
+	return fl
+}
@@ -9,6 +9,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -20,6 +23,8 @@ func main() {
 			"Path under which to expose metrics")
 		configPath = flag.String("config-path", "/etc/lotus_exporter/.env",
 			"Path to environment file")
+		jobDetails = flag.Bool("web.enable-job-details", false,
+			"Expose running jobs exceeding their maximum duration, with sector numbers, on /jobs/overdue")
 	)
 
 	log.Println("Running lotus_exporter")
@@ -42,16 +47,103 @@ func main() {
 	fullNodeApiInfo := os.Getenv("FULLNODE_API_INFO")
 	minerApiInfo := os.Getenv("MINER_API_INFO")
 
-	ltOpt := exporter.LotusOpt{fullNodeApiInfo, minerApiInfo}
+	ltOpt := exporter.LotusOpt{
+		FullNodeApiInfo:  fullNodeApiInfo,
+		MinerApiInfo:     minerApiInfo,
+		MpoolStuckEpochs: getEnvInt("MPOOL_STUCK_EPOCHS", 10),
+		BasefeeWindows:   getEnvIntList("BASEFEE_WINDOWS", []int64{120, 480, 2880}),
+		JobMaxDurations:  getEnvDurations("JOB_MAX_DURATIONS", "PC1=6h,PC2=1h,C2=1h"),
+		JobDetails:       *jobDetails,
+		GasLimits:        getEnvInts("GAS_LIMITS"),
+	}
 
 	exporter.Register(&ltOpt)
 
-	log.Printf("Starting lotus_exporter\n", version.Info())
-	log.Printf("Build context\n", version.BuildContext())
+	log.Printf("Starting lotus_exporter %s\n", version.Info())
+	log.Printf("Build context %s\n", version.BuildContext())
 
 	log.Fatal(serverMetrics(*listenAddress, *metricsPath))
 }
 
+// getEnvInt reads an integer environment variable, falling back to def when it is unset.
+func getEnvInt(name string, def int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	num, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Error parsing %s: %s\n", name, err)
+	}
+	return num
+}
+
+// getEnvIntList reads a comma separated list of positive integers, falling back to def when it is unset.
+func getEnvIntList(name string, def []int64) []int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	var nums []int64
+	for _, field := range strings.Split(value, ",") {
+		num, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing %s: %s\n", name, err)
+		}
+		if num <= 0 {
+			log.Fatalf("Error parsing %s: %d is not positive\n", name, num)
+		}
+		nums = append(nums, num)
+	}
+	return nums
+}
+
+// getEnvDurations reads a comma separated list of NAME=DURATION pairs, falling back to def when it is unset.
+func getEnvDurations(name string, def string) map[string]time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		value = def
+	}
+
+	durations := map[string]time.Duration{}
+	for _, field := range strings.Split(value, ",") {
+		pair := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(pair) != 2 {
+			log.Fatalf("Error parsing %s: expected NAME=DURATION, got %q\n", name, field)
+		}
+		duration, err := time.ParseDuration(pair[1])
+		if err != nil {
+			log.Fatalf("Error parsing %s: %s\n", name, err)
+		}
+		durations[pair[0]] = duration
+	}
+	return durations
+}
+
+// getEnvInts reads a comma separated list of NAME=INTEGER pairs, empty when it is unset.
+func getEnvInts(name string) map[string]int64 {
+	nums := map[string]int64{}
+	value := os.Getenv(name)
+	if value == "" {
+		return nums
+	}
+
+	for _, field := range strings.Split(value, ",") {
+		pair := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(pair) != 2 {
+			log.Fatalf("Error parsing %s: expected NAME=INTEGER, got %q\n", name, field)
+		}
+		num, err := strconv.ParseInt(pair[1], 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing %s: %s\n", name, err)
+		}
+		nums[pair[0]] = num
+	}
+	return nums
+}
+
 func serverMetrics(listenAddress, metricsPath string) error {
 	http.Handle(metricsPath, promhttp.Handler())
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +153,7 @@ func serverMetrics(listenAddress, metricsPath string) error {
 			<body>
 			<h1>Volume Exporter Metrics</h1>
 			<p><a href='` + metricsPath + `'>Metrics</a></p>
+			<p><a href='/mpool/local'>Local mpool messages</a></p>
 			</body>
 			</html>
 		`))